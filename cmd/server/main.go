@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
+	_ "time/tzdata" // Pantry time zones must resolve even without system zoneinfo
 
 	"github.com/byte4bite/byte4bite/internal/api/routes"
 	"github.com/byte4bite/byte4bite/internal/config"
 	"github.com/byte4bite/byte4bite/internal/database"
+	"github.com/byte4bite/byte4bite/internal/jobs"
 	"github.com/gin-gonic/gin"
 )
 
@@ -27,6 +30,9 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Start background jobs
+	jobs.Start(context.Background(), db, cfg)
+
 	// Set Gin mode
 	if cfg.Server.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
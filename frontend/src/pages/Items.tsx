@@ -28,10 +28,15 @@ export const ItemsPage = () => {
         setSelectedPantry(pantry);
       }
 
-      const response = await itemService.listPublic({ search, page: 1, page_size: 50 });
+      const response = await itemService.listPublic({
+        pantry_id: pantryId ?? undefined,
+        search,
+        page: 1,
+        page_size: 50,
+      });
       setItems(response.data);
     } catch (err: any) {
-      setError('Failed to load items');
+      setError(err.response?.data?.error || 'Failed to load items');
     } finally {
       setIsLoading(false);
     }
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/byte4bite/byte4bite/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AssistedOrderHandler handles orders placed by staff for clients and walk-ins
+type AssistedOrderHandler struct {
+	assistedOrderService *services.AssistedOrderService
+}
+
+// NewAssistedOrderHandler creates a new assisted order handler
+func NewAssistedOrderHandler(assistedOrderService *services.AssistedOrderService) *AssistedOrderHandler {
+	return &AssistedOrderHandler{
+		assistedOrderService: assistedOrderService,
+	}
+}
+
+// CreateClientOrder places an order on behalf of an existing client
+// @Summary Create order for client
+// @Description Place an order on behalf of an existing client (admin only)
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param body body services.ClientOrderRequest true "Client and basket"
+// @Success 201 {object} models.Order
+// @Router /api/v1/admin/orders [post]
+func (h *AssistedOrderHandler) CreateClientOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req services.ClientOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.assistedOrderService.CreateClientOrder(userID.(uuid.UUID), &req)
+	if err != nil {
+		respondAssistedOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// CreateWalkInOrder places an order for a walk-in visitor without an account
+// @Summary Create walk-in order
+// @Description Place an order for a visitor identified only by name (admin only)
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param body body services.WalkInOrderRequest true "Visitor and basket"
+// @Success 201 {object} models.Order
+// @Router /api/v1/admin/orders/walk-in [post]
+func (h *AssistedOrderHandler) CreateWalkInOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req services.WalkInOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.assistedOrderService.CreateWalkInOrder(userID.(uuid.UUID), &req)
+	if err != nil {
+		respondAssistedOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// respondAssistedOrderError maps order placement errors to HTTP status codes
+func respondAssistedOrderError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrSlotUnavailable) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	var visitErr *services.VisitLimitError
+	if errors.As(err, &visitErr) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":       visitErr.Error(),
+			"eligible_at": visitErr.EligibleAt,
+		})
+		return
+	}
+	var stockErr *services.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": stockErr.Error(),
+			"items": stockErr.Items,
+		})
+		return
+	}
+	var limitErr *services.QuantityLimitError
+	if errors.As(err, &limitErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      limitErr.Error(),
+			"violations": limitErr.Violations,
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
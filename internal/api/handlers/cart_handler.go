@@ -1,9 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 
-	"github.com/byte4bite/byte4bite/internal/repositories"
 	"github.com/byte4bite/byte4bite/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -11,20 +12,39 @@ import (
 
 // CartHandler handles cart-related endpoints
 type CartHandler struct {
-	cartService  *services.CartService
-	orderRepo    *repositories.OrderRepository
+	cartService *services.CartService
 }
 
 // NewCartHandler creates a new cart handler
-func NewCartHandler(cartService *services.CartService, orderRepo *repositories.OrderRepository) *CartHandler {
+func NewCartHandler(cartService *services.CartService) *CartHandler {
 	return &CartHandler{
 		cartService: cartService,
-		orderRepo:   orderRepo,
 	}
 }
 
-// GetCurrentCart retrieves the current active cart for the user
-// GET /api/v1/carts/current
+// GetActiveCarts lists the user's active carts, one per pantry
+// GET /api/v1/carts
+func (h *CartHandler) GetActiveCarts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	carts, err := h.cartService.GetActiveCarts(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get carts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"carts": carts,
+		"count": len(carts),
+	})
+}
+
+// GetCurrentCart retrieves the user's active cart at the selected pantry
+// GET /api/v1/carts/current?pantry_id=
 func (h *CartHandler) GetCurrentCart(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -32,8 +52,17 @@ func (h *CartHandler) GetCurrentCart(c *gin.Context) {
 		return
 	}
 
-	cart, err := h.cartService.GetCurrentCart(userID.(uuid.UUID))
+	pantryID, ok := selectedPantry(c)
+	if !ok {
+		return
+	}
+
+	cart, err := h.cartService.GetCurrentCart(userID.(uuid.UUID), pantryID)
 	if err != nil {
+		if errors.Is(err, services.ErrPantryRequired) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart"})
 		return
 	}
@@ -47,26 +76,51 @@ func (h *CartHandler) GetCurrentCart(c *gin.Context) {
 		return
 	}
 
+	allowances, err := h.cartService.GetAllowances(cart)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"cart":  cart,
-		"items": cart.Items,
-		"count": len(cart.Items),
+		"cart":       cart,
+		"items":      cart.Items,
+		"count":      len(cart.Items),
+		"allowances": allowances,
 	})
 }
 
-// AddItem adds an item to the cart
-// POST /api/v1/carts/items
-func (h *CartHandler) AddItem(c *gin.Context) {
+// ValidateCart checks the cart at the selected pantry for lines that would fail
+// checkout, optionally clamping or removing them
+// GET /api/v1/carts/current/validate?pantry_id=&fix=true
+func (h *CartHandler) ValidateCart(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	// For now, using a default pantry ID - should come from user context or request
-	pantryID := uuid.MustParse("00000000-0000-0000-0000-000000000000")
-	if pantryIDValue, exists := c.Get("pantry_id"); exists {
-		pantryID = pantryIDValue.(uuid.UUID)
+	pantryID, ok := selectedPantry(c)
+	if !ok {
+		return
+	}
+
+	validation, err := h.cartService.ValidateCart(userID.(uuid.UUID), pantryID, c.Query("fix") == "true")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, validation)
+}
+
+// AddItem adds an item to the cart at the item's pantry
+// POST /api/v1/carts/items
+func (h *CartHandler) AddItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
 	}
 
 	var req services.AddItemRequest
@@ -75,9 +129,9 @@ func (h *CartHandler) AddItem(c *gin.Context) {
 		return
 	}
 
-	cart, err := h.cartService.AddItem(userID.(uuid.UUID), pantryID, &req)
+	cart, err := h.cartService.AddItem(userID.(uuid.UUID), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondCartError(c, err)
 		return
 	}
 
@@ -107,7 +161,7 @@ func (h *CartHandler) UpdateItemQuantity(c *gin.Context) {
 
 	cart, err := h.cartService.UpdateItemQuantity(userID.(uuid.UUID), cartItemID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondCartError(c, err)
 		return
 	}
 
@@ -138,8 +192,8 @@ func (h *CartHandler) RemoveItem(c *gin.Context) {
 	c.JSON(http.StatusOK, cart)
 }
 
-// ClearCart removes all items from the cart
-// DELETE /api/v1/carts/current
+// ClearCart removes all items from the cart at the selected pantry
+// DELETE /api/v1/carts/current?pantry_id=
 func (h *CartHandler) ClearCart(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -147,7 +201,12 @@ func (h *CartHandler) ClearCart(c *gin.Context) {
 		return
 	}
 
-	if err := h.cartService.ClearCart(userID.(uuid.UUID)); err != nil {
+	pantryID, ok := selectedPantry(c)
+	if !ok {
+		return
+	}
+
+	if err := h.cartService.ClearCart(userID.(uuid.UUID), pantryID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -155,8 +214,8 @@ func (h *CartHandler) ClearCart(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Cart cleared successfully"})
 }
 
-// Checkout converts the cart to an order
-// POST /api/v1/carts/checkout
+// Checkout converts the cart at the selected pantry to an order
+// POST /api/v1/carts/checkout?pantry_id=
 func (h *CartHandler) Checkout(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -164,30 +223,41 @@ func (h *CartHandler) Checkout(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Notes string `json:"notes"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		// Notes are optional, so ignore binding errors
-		req.Notes = ""
-	}
-
-	order, err := h.cartService.Checkout(userID.(uuid.UUID), req.Notes)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	pantryID, ok := selectedPantry(c)
+	if !ok {
 		return
 	}
 
-	// Save the order
-	if err := h.orderRepo.Create(order); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+	var req services.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		// An empty body is allowed since every field is optional
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Reload order with associations
-	order, err = h.orderRepo.FindByID(order.ID)
+	order, err := h.cartService.Checkout(userID.(uuid.UUID), pantryID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve order"})
+		if errors.Is(err, services.ErrSlotUnavailable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		var limitErr *services.VisitLimitError
+		if errors.As(err, &limitErr) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":       limitErr.Error(),
+				"eligible_at": limitErr.EligibleAt,
+			})
+			return
+		}
+		var stockErr *services.InsufficientStockError
+		if errors.As(err, &stockErr) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": stockErr.Error(),
+				"items": stockErr.Items,
+			})
+			return
+		}
+		respondCartError(c, err)
 		return
 	}
 
@@ -196,3 +266,60 @@ func (h *CartHandler) Checkout(c *gin.Context) {
 		"order":   order,
 	})
 }
+
+// Reorder fills the current cart with the items of a previous order
+// POST /api/v1/orders/:id/reorder
+func (h *CartHandler) Reorder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	result, err := h.cartService.Reorder(userID.(uuid.UUID), orderID)
+	if err != nil {
+		if err.Error() == "unauthorized to reorder this order" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// selectedPantry reads the optional pantry_id query parameter that selects one
+// of the user's carts. It responds with 400 and returns false if it is invalid.
+func selectedPantry(c *gin.Context) (*uuid.UUID, bool) {
+	param := c.Query("pantry_id")
+	if param == "" {
+		return nil, true
+	}
+	pantryID, err := uuid.Parse(param)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pantry ID"})
+		return nil, false
+	}
+	return &pantryID, true
+}
+
+// respondCartError reports cart errors, listing the exceeded limits when a
+// per-order quantity limit was hit
+func respondCartError(c *gin.Context, err error) {
+	var limitErr *services.QuantityLimitError
+	if errors.As(err, &limitErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      limitErr.Error(),
+			"violations": limitErr.Violations,
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/byte4bite/byte4bite/internal/barcode"
+	"github.com/byte4bite/byte4bite/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CatalogHandler handles product catalog endpoints
+type CatalogHandler struct {
+	catalogService *services.CatalogService
+}
+
+// NewCatalogHandler creates a new catalog handler
+func NewCatalogHandler(catalogService *services.CatalogService) *CatalogHandler {
+	return &CatalogHandler{
+		catalogService: catalogService,
+	}
+}
+
+// ImportCatalog imports products from an uploaded CSV catalog file
+// POST /api/v1/admin/catalog/import (multipart form, field "file")
+func (h *CatalogHandler) ImportCatalog(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a CSV file is required in the file field"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	result, err := h.catalogService.ImportCSV(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetProduct looks up a catalog product by barcode
+// GET /api/v1/admin/catalog/:code
+func (h *CatalogHandler) GetProduct(c *gin.Context) {
+	product, err := h.catalogService.GetProduct(c.Param("code"))
+	if err != nil {
+		if errors.Is(err, barcode.ErrInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DeliveryHandler handles home delivery endpoints
+type DeliveryHandler struct {
+	deliveryService *services.DeliveryService
+}
+
+// NewDeliveryHandler creates a new delivery handler
+func NewDeliveryHandler(deliveryService *services.DeliveryService) *DeliveryHandler {
+	return &DeliveryHandler{
+		deliveryService: deliveryService,
+	}
+}
+
+// AssignDriver assigns a driver to a delivery order
+// @Summary Assign driver
+// @Description Assign a driver to a delivery order (admin only)
+// @Tags deliveries
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param body body services.AssignDriverRequest true "Driver"
+// @Success 200 {object} map[string]string
+// @Router /api/v1/admin/orders/{id}/driver [put]
+func (h *DeliveryHandler) AssignDriver(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID"})
+		return
+	}
+
+	var req services.AssignDriverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.deliveryService.AssignDriver(orderID, userID.(uuid.UUID), req.DriverID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "driver assigned successfully"})
+}
+
+// GetRunSheet returns delivery orders grouped by driver and day
+// @Summary Get delivery run sheet
+// @Description Get a pantry's delivery orders grouped by driver and day, sorted by zip code (admin only)
+// @Tags deliveries
+// @Produce json
+// @Param pantry_id query string true "Pantry ID"
+// @Param date query string false "Delivery date (YYYY-MM-DD)"
+// @Param status query string false "Order status (default ready)"
+// @Success 200 {array} services.DriverRun
+// @Router /api/v1/admin/deliveries/run-sheet [get]
+func (h *DeliveryHandler) GetRunSheet(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Query("pantry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	runs, err := h.deliveryService.GetRunSheet(pantryID, c.Query("date"), models.OrderStatus(c.Query("status")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
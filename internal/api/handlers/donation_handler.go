@@ -192,6 +192,68 @@ func (h *DonationHandler) DeleteDonation(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "donation deleted successfully"})
 }
 
+// SetDonationItems replaces the items of a donation (admin only)
+// @Summary Set donation items
+// @Description Replace the in-kind items of a donation that has not been received
+// @Tags donations
+// @Accept json
+// @Produce json
+// @Param id path string true "Donation ID"
+// @Param body body services.SetDonationItemsRequest true "Donation items"
+// @Success 200 {object} models.Donation
+// @Router /api/v1/admin/donations/{id}/items [put]
+func (h *DonationHandler) SetDonationItems(c *gin.Context) {
+	donationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid donation ID"})
+		return
+	}
+
+	var req services.SetDonationItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	donation, err := h.donationService.SetItems(donationID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, donation)
+}
+
+// ReceiveDonation adds a donation's items to inventory (admin only)
+// @Summary Receive donation into inventory
+// @Description Add the in-kind items of a donation to its pantry's stock
+// @Tags donations
+// @Produce json
+// @Param id path string true "Donation ID"
+// @Success 200 {object} models.Donation
+// @Router /api/v1/admin/donations/{id}/receive [post]
+func (h *DonationHandler) ReceiveDonation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	donationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid donation ID"})
+		return
+	}
+
+	donation, err := h.donationService.ReceiveDonation(userID.(uuid.UUID), donationID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, donation)
+}
+
 // MarkReceiptSent marks a donation receipt as sent (admin only)
 // @Summary Mark receipt as sent
 // @Description Mark a donation receipt as sent
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
+	"github.com/byte4bite/byte4bite/internal/barcode"
 	"github.com/byte4bite/byte4bite/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -23,13 +27,19 @@ func NewItemHandler(itemService *services.ItemService) *ItemHandler {
 // CreateItem creates a new item
 // POST /api/v1/admin/items
 func (h *ItemHandler) CreateItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
 	var req services.CreateItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	item, err := h.itemService.CreateItem(&req)
+	item, err := h.itemService.CreateItem(userID.(uuid.UUID), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create item"})
 		return
@@ -93,6 +103,12 @@ func (h *ItemHandler) ListItems(c *gin.Context) {
 // UpdateItem updates an item
 // PUT /api/v1/admin/items/:id
 func (h *ItemHandler) UpdateItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
 	idParam := c.Param("id")
 	id, err := uuid.Parse(idParam)
 	if err != nil {
@@ -106,7 +122,7 @@ func (h *ItemHandler) UpdateItem(c *gin.Context) {
 		return
 	}
 
-	item, err := h.itemService.UpdateItem(id, &req)
+	item, err := h.itemService.UpdateItem(userID.(uuid.UUID), id, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update item"})
 		return
@@ -133,9 +149,15 @@ func (h *ItemHandler) DeleteItem(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
 }
 
-// UpdateItemQuantity updates the quantity of an item
+// UpdateItemQuantity sets the counted quantity of an item
 // PATCH /api/v1/admin/items/:id/quantity
 func (h *ItemHandler) UpdateItemQuantity(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
 	idParam := c.Param("id")
 	id, err := uuid.Parse(idParam)
 	if err != nil {
@@ -144,14 +166,15 @@ func (h *ItemHandler) UpdateItemQuantity(c *gin.Context) {
 	}
 
 	var req struct {
-		Quantity int `json:"quantity" binding:"required,min=0"`
+		Quantity int    `json:"quantity" binding:"min=0"`
+		Reason   string `json:"reason"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.itemService.UpdateItemQuantity(id, req.Quantity); err != nil {
+	if err := h.itemService.UpdateItemQuantity(userID.(uuid.UUID), id, req.Quantity, req.Reason); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update quantity"})
 		return
 	}
@@ -159,6 +182,283 @@ func (h *ItemHandler) UpdateItemQuantity(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Quantity updated successfully"})
 }
 
+// RecordMovement applies a manual stock movement to an item
+// POST /api/v1/admin/items/:id/movements
+func (h *ItemHandler) RecordMovement(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	var req services.StockMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.itemService.RecordMovement(userID.(uuid.UUID), id, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrNotEnoughStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// GetMovements lists the stock movements of an item
+// GET /api/v1/admin/items/:id/movements?type=&page=&page_size=
+func (h *ItemHandler) GetMovements(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	var req services.ListMovementsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movements, err := h.itemService.GetMovements(id, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, movements)
+}
+
+// GetItemByBarcode finds an item of a pantry by its barcode
+// GET /api/v1/admin/items/by-barcode/:code?pantry_id=
+func (h *ItemHandler) GetItemByBarcode(c *gin.Context) {
+	pantryID, ok := staffPantry(c, c.Query("pantry_id"))
+	if !ok {
+		return
+	}
+
+	item, err := h.itemService.GetItemByBarcode(pantryID, c.Param("code"))
+	if err != nil {
+		if errors.Is(err, barcode.ErrInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ScanIntake receives stock for a scanned barcode, or offers a prefilled new
+// item when the barcode is unknown
+// POST /api/v1/admin/items/scan
+func (h *ItemHandler) ScanIntake(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req services.ScanIntakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.PantryID == uuid.Nil {
+		pantryID, ok := staffPantry(c, "")
+		if !ok {
+			return
+		}
+		req.PantryID = pantryID
+	}
+
+	result, err := h.itemService.ScanIntake(userID.(uuid.UUID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ImportItems creates items from an uploaded CSV or XLSX spreadsheet
+// POST /api/v1/admin/items/import?pantry_id=&dry_run=true&format=csv|xlsx (multipart form, field "file")
+func (h *ItemHandler) ImportItems(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	pantryID, ok := staffPantry(c, c.Query("pantry_id"))
+	if !ok {
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a CSV or XLSX file is required in the file field"})
+		return
+	}
+	format := services.ImportFormat(c.Query("format"), header.Filename)
+	if format != services.FormatCSV && format != services.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	req := services.ImportItemsRequest{
+		PantryID: pantryID,
+		Format:   format,
+		DryRun:   c.Query("dry_run") == "true",
+	}
+	result, err := h.itemService.ImportItems(userID.(uuid.UUID), &req, file, header.Size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case len(result.Errors) > 0:
+		c.JSON(http.StatusUnprocessableEntity, result)
+	case result.DryRun:
+		c.JSON(http.StatusOK, result)
+	default:
+		c.JSON(http.StatusCreated, result)
+	}
+}
+
+// ExportItems streams a pantry's items and stock levels as a spreadsheet
+// GET /api/v1/admin/items/export?pantry_id=&format=csv|xlsx
+func (h *ItemHandler) ExportItems(c *gin.Context) {
+	pantryID, ok := staffPantry(c, c.Query("pantry_id"))
+	if !ok {
+		return
+	}
+
+	var contentType string
+	format := c.DefaultQuery("format", services.FormatCSV)
+	switch format {
+	case services.FormatCSV:
+		contentType = "text/csv"
+	case services.FormatXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="items-`+pantryID.String()+"."+format+`"`)
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+
+	// The response is already under way, so a failure can only be logged
+	if err := h.itemService.ExportItems(pantryID, format, c.Writer); err != nil {
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// GetLots lists the lots of an item that still hold stock
+// GET /api/v1/admin/items/:id/lots
+func (h *ItemHandler) GetLots(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	lots, err := h.itemService.GetLots(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  lots,
+		"count": len(lots),
+	})
+}
+
+// GetExpiringLots reports lots that have expired or expire soon
+// GET /api/v1/admin/items/expiring?days=7&pantry_id=
+func (h *ItemHandler) GetExpiringLots(c *gin.Context) {
+	var pantryID *uuid.UUID
+	if pantryIDParam := c.Query("pantry_id"); pantryIDParam != "" {
+		id, err := uuid.Parse(pantryIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pantry ID"})
+			return
+		}
+		pantryID = &id
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a number"})
+		return
+	}
+
+	report, err := h.itemService.GetExpiring(pantryID, days)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// DiscardLot logs stock thrown away from a lot as spoilage
+// POST /api/v1/admin/items/lots/:id/discard
+func (h *ItemHandler) DiscardLot(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lot ID"})
+		return
+	}
+
+	var req services.DiscardLotRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lot, err := h.itemService.DiscardLot(userID.(uuid.UUID), id, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrNotEnoughStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lot)
+}
+
 // GetLowStockItems retrieves items that are low on stock
 // GET /api/v1/admin/items/low-stock
 func (h *ItemHandler) GetLowStockItems(c *gin.Context) {
@@ -184,18 +484,29 @@ func (h *ItemHandler) GetLowStockItems(c *gin.Context) {
 	})
 }
 
-// ListItemsPublic lists available items for regular users
-// GET /api/v1/items
+// ListItemsPublic lists the available items of the selected pantry for regular
+// users, defaulting to the pantry of the signed in user
+// GET /api/v1/items?pantry_id=
 func (h *ItemHandler) ListItemsPublic(c *gin.Context) {
 	var req services.ListItemsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.PantryID == nil {
+		pantryID, exists := c.Get("pantry_id")
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pantry_id is required"})
+			return
+		}
+		id := pantryID.(uuid.UUID)
+		req.PantryID = &id
+	}
 
 	// Force available filter for public endpoint
 	available := true
 	req.Available = &available
+	req.ActivePantry = true
 
 	items, total, err := h.itemService.ListItems(&req)
 	if err != nil {
@@ -220,3 +531,21 @@ func (h *ItemHandler) ListItemsPublic(c *gin.Context) {
 		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
 	})
 }
+
+// staffPantry resolves the pantry an admin request is about: the given ID,
+// or else the pantry of the signed in staff member
+func staffPantry(c *gin.Context, pantryIDParam string) (uuid.UUID, bool) {
+	if pantryIDParam != "" {
+		pantryID, err := uuid.Parse(pantryIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pantry ID"})
+			return uuid.Nil, false
+		}
+		return pantryID, true
+	}
+	if pantryID, exists := c.Get("pantry_id"); exists {
+		return pantryID.(uuid.UUID), true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "pantry_id is required"})
+	return uuid.Nil, false
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/byte4bite/byte4bite/internal/services"
@@ -28,18 +31,25 @@ func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
 // @Tags orders
 // @Produce json
 // @Param status query string false "Filter by status"
+// @Param pantry_id query string false "Filter by pantry (admin only)"
+// @Param start_date query string false "Submitted on or after (YYYY-MM-DD, admin only)"
+// @Param end_date query string false "Submitted on or before (YYYY-MM-DD, admin only)"
+// @Param assigned_to query string false "Staff ID, me or unassigned (admin only)"
+// @Param search query string false "Client name or email (admin only)"
+// @Param pickup_slot_id query string false "Filter by pickup slot (admin only)"
+// @Param sort query string false "created_at, submitted_at, pickup_start or status; prefix with - for descending (admin only)"
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Page size" default(20)
 // @Success 200 {object} services.GetOrdersResponse
 // @Router /api/v1/orders [get]
 func (h *OrderHandler) GetOrders(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	role, _ := c.Get("role")
+	role, _ := c.Get("user_role")
 	isAdmin := role == string(models.RoleAdmin)
 
 	// Parse query parameters
@@ -50,6 +60,12 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 		req.Status = &status
 	}
 
+	if isAdmin {
+		if !parseOrderFilters(c, userID.(uuid.UUID), &req) {
+			return
+		}
+	}
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 
@@ -57,6 +73,10 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 	req.PageSize = pageSize
 
 	response, err := h.orderService.GetOrders(userID.(uuid.UUID), isAdmin, req)
+	if errors.Is(err, services.ErrInvalidOrderSort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -65,6 +85,67 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// parseOrderFilters reads the admin order filters from the query string,
+// responding with an error and returning false if one is malformed
+func parseOrderFilters(c *gin.Context, userID uuid.UUID, req *services.GetOrdersRequest) bool {
+	parseID := func(name string) (*uuid.UUID, bool) {
+		value := c.Query(name)
+		if value == "" {
+			return nil, true
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+			return nil, false
+		}
+		return &id, true
+	}
+
+	var ok bool
+	if req.PantryID, ok = parseID("pantry_id"); !ok {
+		return false
+	}
+	if req.PickupSlotID, ok = parseID("pickup_slot_id"); !ok {
+		return false
+	}
+
+	switch assignedTo := c.Query("assigned_to"); assignedTo {
+	case "":
+	case "me":
+		req.AssignedToID = &userID
+	case "unassigned":
+		req.Unassigned = true
+	default:
+		if req.AssignedToID, ok = parseID("assigned_to"); !ok {
+			return false
+		}
+	}
+
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		date, err := time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start date format (use YYYY-MM-DD)"})
+			return false
+		}
+		req.SubmittedFrom = &date
+	}
+
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		date, err := time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end date format (use YYYY-MM-DD)"})
+			return false
+		}
+		// Include the whole end day
+		date = date.AddDate(0, 0, 1)
+		req.SubmittedTo = &date
+	}
+
+	req.Search = c.Query("search")
+	req.Sort = c.Query("sort")
+	return true
+}
+
 // GetOrder returns a single order by ID
 // @Summary Get order by ID
 // @Description Get detailed information about a specific order
@@ -74,13 +155,13 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 // @Success 200 {object} models.Order
 // @Router /api/v1/orders/{id} [get]
 func (h *OrderHandler) GetOrder(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	role, _ := c.Get("role")
+	role, _ := c.Get("user_role")
 	isAdmin := role == string(models.RoleAdmin)
 
 	orderID, err := uuid.Parse(c.Param("id"))
@@ -113,6 +194,12 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 // @Success 200 {object} map[string]string
 // @Router /api/v1/orders/{id}/status [put]
 func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
 	orderID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID"})
@@ -125,7 +212,7 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.orderService.UpdateOrderStatus(orderID, req.Status); err != nil {
+	if err := h.orderService.UpdateOrderStatus(orderID, userID.(uuid.UUID), req.Status, req.Reason); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -144,6 +231,12 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 // @Success 200 {object} map[string]string
 // @Router /api/v1/orders/{id}/assign [put]
 func (h *OrderHandler) AssignStaff(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
 	orderID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID"})
@@ -156,7 +249,7 @@ func (h *OrderHandler) AssignStaff(c *gin.Context) {
 		return
 	}
 
-	if err := h.orderService.AssignStaff(orderID, req.StaffID); err != nil {
+	if err := h.orderService.AssignStaff(orderID, userID.(uuid.UUID), req.StaffID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -170,16 +263,17 @@ func (h *OrderHandler) AssignStaff(c *gin.Context) {
 // @Tags orders
 // @Produce json
 // @Param id path string true "Order ID"
+// @Param body body services.CancelOrderRequest false "Cancellation reason"
 // @Success 200 {object} map[string]string
 // @Router /api/v1/orders/{id} [delete]
 func (h *OrderHandler) CancelOrder(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	role, _ := c.Get("role")
+	role, _ := c.Get("user_role")
 	isAdmin := role == string(models.RoleAdmin)
 
 	orderID, err := uuid.Parse(c.Param("id"))
@@ -188,7 +282,14 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 		return
 	}
 
-	if err := h.orderService.CancelOrder(orderID, userID.(uuid.UUID), isAdmin); err != nil {
+	var req services.CancelOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		// The reason is optional, so an empty body is allowed
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.orderService.CancelOrder(orderID, userID.(uuid.UUID), isAdmin, req.Reason); err != nil {
 		if err.Error() == "unauthorized to cancel this order" {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
@@ -199,3 +300,258 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "order cancelled successfully"})
 }
+
+// GetOrderHistory returns the status history of an order
+// @Summary Get order history
+// @Description Get the audit trail of an order (users see their own, admins see all)
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {array} models.OrderEvent
+// @Router /api/v1/orders/{id}/history [get]
+func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	role, _ := c.Get("user_role")
+	isAdmin := role == string(models.RoleAdmin)
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID"})
+		return
+	}
+
+	events, err := h.orderService.GetOrderHistory(orderID, userID.(uuid.UUID), isAdmin)
+	if err != nil {
+		if err.Error() == "unauthorized to view this order" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  events,
+		"count": len(events),
+	})
+}
+
+// BulkUpdateOrders applies one action to several orders (admin only)
+// @Summary Bulk update orders
+// @Description Change the status of, assign or cancel several orders, reporting the result per order
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param body body services.BulkOrderRequest true "Orders and action"
+// @Success 200 {object} services.BulkOrderResponse
+// @Router /api/v1/admin/orders/bulk [post]
+func (h *OrderHandler) BulkUpdateOrders(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req services.BulkOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.orderService.BulkUpdate(userID.(uuid.UUID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateOrderItems changes the items of the user's own pending order
+// @Summary Update order items
+// @Description Add, remove or change item quantities while the order is still in its initial status
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param body body services.UpdateOrderItemsRequest true "Item changes"
+// @Success 200 {object} models.Order
+// @Router /api/v1/orders/{id}/items [put]
+func (h *OrderHandler) UpdateOrderItems(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID"})
+		return
+	}
+
+	var req services.UpdateOrderItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.orderService.UpdateOrderItems(orderID, userID.(uuid.UUID), &req)
+	if err != nil {
+		if err.Error() == "unauthorized to edit this order" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		respondOrderLineError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// AdjustOrderLine changes the quantity of an order line while preparing (admin only)
+// @Summary Adjust order line
+// @Description Change the fulfilled quantity of an order line; 0 removes it
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param line_id path string true "Order line ID"
+// @Param body body services.AdjustOrderLineRequest true "New quantity"
+// @Success 200 {object} models.Order
+// @Router /api/v1/admin/orders/{id}/items/{line_id} [put]
+func (h *OrderHandler) AdjustOrderLine(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	orderID, lineID, ok := parseOrderLine(c)
+	if !ok {
+		return
+	}
+
+	var req services.AdjustOrderLineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.orderService.AdjustOrderLine(orderID, lineID, userID.(uuid.UUID), *req.Quantity, req.Reason)
+	if err != nil {
+		respondOrderLineError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// RemoveOrderLine removes an order line while preparing (admin only)
+// @Summary Remove order line
+// @Description Remove a line from an order; it stays on record with quantity 0
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param line_id path string true "Order line ID"
+// @Success 200 {object} models.Order
+// @Router /api/v1/admin/orders/{id}/items/{line_id} [delete]
+func (h *OrderHandler) RemoveOrderLine(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	orderID, lineID, ok := parseOrderLine(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.orderService.AdjustOrderLine(orderID, lineID, userID.(uuid.UUID), 0, c.Query("reason"))
+	if err != nil {
+		respondOrderLineError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// SubstituteOrderLine replaces the item of an order line while preparing (admin only)
+// @Summary Substitute order line item
+// @Description Replace the item of an order line with a different item
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param line_id path string true "Order line ID"
+// @Param body body services.SubstituteOrderLineRequest true "Substitute item"
+// @Success 200 {object} models.Order
+// @Router /api/v1/admin/orders/{id}/items/{line_id}/substitute [post]
+func (h *OrderHandler) SubstituteOrderLine(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	orderID, lineID, ok := parseOrderLine(c)
+	if !ok {
+		return
+	}
+
+	var req services.SubstituteOrderLineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.orderService.SubstituteOrderLine(orderID, lineID, userID.(uuid.UUID), &req)
+	if err != nil {
+		respondOrderLineError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// parseOrderLine parses the order and line IDs from the path
+func parseOrderLine(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	lineID, err := uuid.Parse(c.Param("line_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order line ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return orderID, lineID, true
+}
+
+// respondOrderLineError maps order line edit errors to HTTP status codes
+func respondOrderLineError(c *gin.Context, err error) {
+	var stockErr *services.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": stockErr.Error(),
+			"items": stockErr.Items,
+		})
+		return
+	}
+	var limitErr *services.QuantityLimitError
+	if errors.As(err, &limitErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      limitErr.Error(),
+			"violations": limitErr.Violations,
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PickListHandler handles pick list and packing slip endpoints
+type PickListHandler struct {
+	pickListService *services.PickListService
+}
+
+// NewPickListHandler creates a new pick list handler
+func NewPickListHandler(pickListService *services.PickListService) *PickListHandler {
+	return &PickListHandler{
+		pickListService: pickListService,
+	}
+}
+
+// GetPickList returns the aggregated items of a pantry's orders
+// GET /api/v1/admin/pick-lists?pantry_id=&status=preparing&date=YYYY-MM-DD&format=json|csv|pdf
+func (h *PickListHandler) GetPickList(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Query("pantry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	list, err := h.pickListService.GetPickList(pantryID, models.OrderStatus(c.Query("status")), c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, list)
+	case "csv":
+		data, err := services.PickListCSV(list)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render pick list"})
+			return
+		}
+		attachment(c, "pick-list.csv", "text/csv", data)
+	case "pdf":
+		attachment(c, "pick-list.pdf", "application/pdf", services.PickListPDF(list))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv or pdf"})
+	}
+}
+
+// GetPackingSlip returns the packing slip of an order
+// GET /api/v1/admin/orders/:id/packing-slip?format=json|csv|pdf
+func (h *PickListHandler) GetPackingSlip(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID"})
+		return
+	}
+
+	slip, err := h.pickListService.GetPackingSlip(orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := "packing-slip-" + slip.OrderID.String()
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, slip)
+	case "csv":
+		data, err := services.PackingSlipCSV(slip)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render packing slip"})
+			return
+		}
+		attachment(c, filename+".csv", "text/csv", data)
+	case "pdf":
+		attachment(c, filename+".pdf", "application/pdf", services.PackingSlipPDF(slip))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv or pdf"})
+	}
+}
+
+// attachment sends data as a downloadable file
+func attachment(c *gin.Context, filename, contentType string, data []byte) {
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PickupSlotHandler handles pickup slot scheduling endpoints
+type PickupSlotHandler struct {
+	slotService *services.PickupSlotService
+}
+
+// NewPickupSlotHandler creates a new pickup slot handler
+func NewPickupSlotHandler(slotService *services.PickupSlotService) *PickupSlotHandler {
+	return &PickupSlotHandler{
+		slotService: slotService,
+	}
+}
+
+// GetPickupSlots lists the pickup slots of a pantry for a day
+// GET /api/v1/pantries/:id/pickup-slots?date=YYYY-MM-DD
+func (h *PickupSlotHandler) GetPickupSlots(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	date := c.Query("date")
+	if date == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date is required"})
+		return
+	}
+
+	slots, err := h.slotService.GetSlotsForDate(pantryID, date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  slots,
+		"count": len(slots),
+	})
+}
+
+// ListTemplates lists the slot templates of a pantry
+// GET /api/v1/admin/pantries/:id/slot-templates
+func (h *PickupSlotHandler) ListTemplates(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	templates, err := h.slotService.GetTemplates(pantryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get slot templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  templates,
+		"count": len(templates),
+	})
+}
+
+// CreateTemplate creates a slot template for a pantry
+// POST /api/v1/admin/pantries/:id/slot-templates
+func (h *PickupSlotHandler) CreateTemplate(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	var req services.CreateSlotTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.slotService.CreateTemplate(pantryID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// UpdateTemplate updates a slot template
+// PUT /api/v1/admin/pantries/:id/slot-templates/:template_id
+func (h *PickupSlotHandler) UpdateTemplate(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("template_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template ID"})
+		return
+	}
+
+	var req services.UpdateSlotTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.slotService.UpdateTemplate(pantryID, templateID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrTemplateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteTemplate deletes a slot template
+// DELETE /api/v1/admin/pantries/:id/slot-templates/:template_id
+func (h *PickupSlotHandler) DeleteTemplate(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("template_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template ID"})
+		return
+	}
+
+	if err := h.slotService.DeleteTemplate(pantryID, templateID); err != nil {
+		if errors.Is(err, services.ErrTemplateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete slot template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "slot template deleted successfully"})
+}
+
+// ReschedulePickup moves an order to another pickup slot
+// PUT /api/v1/orders/:id/pickup-slot
+func (h *PickupSlotHandler) ReschedulePickup(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	role, _ := c.Get("user_role")
+	isAdmin := role == string(models.RoleAdmin)
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID"})
+		return
+	}
+
+	var req services.ReschedulePickupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.slotService.Reschedule(orderID, userID.(uuid.UUID), isAdmin, req.PickupSlotID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelPickup frees the pickup slot booked by an order
+// DELETE /api/v1/orders/:id/pickup-slot
+func (h *PickupSlotHandler) CancelPickup(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	role, _ := c.Get("user_role")
+	isAdmin := role == string(models.RoleAdmin)
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID"})
+		return
+	}
+
+	order, err := h.slotService.CancelBooking(orderID, userID.(uuid.UUID), isAdmin)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// respondError maps booking errors to HTTP status codes
+func (h *PickupSlotHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "unauthorized to modify this order":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSlotUnavailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
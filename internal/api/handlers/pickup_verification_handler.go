@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PickupVerificationHandler handles pickup code and QR check-in endpoints
+type PickupVerificationHandler struct {
+	verificationService *services.PickupVerificationService
+}
+
+// NewPickupVerificationHandler creates a new pickup verification handler
+func NewPickupVerificationHandler(verificationService *services.PickupVerificationService) *PickupVerificationHandler {
+	return &PickupVerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+// GetPickupPass returns the pickup code and QR token of an order
+// GET /api/v1/orders/:id/pickup-pass
+func (h *PickupVerificationHandler) GetPickupPass(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	role, _ := c.Get("user_role")
+	isAdmin := role == string(models.RoleAdmin)
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID"})
+		return
+	}
+
+	pass, err := h.verificationService.GetPickupPass(orderID, userID.(uuid.UUID), isAdmin)
+	if err != nil {
+		if err.Error() == "unauthorized to view this order" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pass)
+}
+
+// VerifyPickup checks a pickup code or QR token and marks the order picked up
+// POST /api/v1/admin/orders/verify
+func (h *PickupVerificationHandler) VerifyPickup(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req services.VerifyPickupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.verificationService.Verify(userID.(uuid.UUID), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTooManyAttempts):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidPickupCode):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "order picked up",
+		"order":   order,
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ReportHandler handles reporting endpoints
+type ReportHandler struct {
+	reportService *services.ReportService
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// GetDistributionReport returns requested versus distributed quantities per item
+// @Summary Get distribution report
+// @Description Compare requested and distributed quantities per item over completed orders (admin only)
+// @Tags reports
+// @Produce json
+// @Param pantry_id query string false "Filter by pantry ID"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date, inclusive (YYYY-MM-DD)"
+// @Success 200 {object} services.DistributionReport
+// @Router /api/v1/admin/reports/distribution [get]
+func (h *ReportHandler) GetDistributionReport(c *gin.Context) {
+	var pantryID *uuid.UUID
+	var startDate, endDate *time.Time
+
+	if pantryIDStr := c.Query("pantry_id"); pantryIDStr != "" {
+		id, err := uuid.Parse(pantryIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+			return
+		}
+		pantryID = &id
+	}
+
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		date, err := time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start date format (use YYYY-MM-DD)"})
+			return
+		}
+		startDate = &date
+	}
+
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		date, err := time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end date format (use YYYY-MM-DD)"})
+			return
+		}
+		// Include the whole end day
+		date = date.AddDate(0, 0, 1)
+		endDate = &date
+	}
+
+	report, err := h.reportService.GetDistributionReport(pantryID, startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build distribution report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/byte4bite/byte4bite/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// StaffHandler handles staff assignment endpoints
+type StaffHandler struct {
+	staffService *services.StaffService
+}
+
+// NewStaffHandler creates a new staff handler
+func NewStaffHandler(staffService *services.StaffService) *StaffHandler {
+	return &StaffHandler{
+		staffService: staffService,
+	}
+}
+
+// GetWorkload returns the open orders per staff member of a pantry
+// @Summary Get staff workload
+// @Description Show open orders per staff member of a pantry (admin only)
+// @Tags staff
+// @Produce json
+// @Param pantry_id query string true "Pantry ID"
+// @Success 200 {object} services.PantryWorkload
+// @Router /api/v1/admin/staff/workload [get]
+func (h *StaffHandler) GetWorkload(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Query("pantry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	workload, err := h.staffService.GetWorkload(pantryID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, workload)
+}
@@ -27,6 +27,8 @@ type UpdateProfileRequest struct {
 	LastName  string     `json:"last_name"`
 	Phone     string     `json:"phone"`
 	PantryID  *uuid.UUID `json:"pantry_id"`
+
+	HouseholdSize *int `json:"household_size" binding:"omitempty,min=1"`
 }
 
 // UpdatePassword represents a password update request
@@ -87,6 +89,9 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	if req.PantryID != nil {
 		user.PantryID = req.PantryID
 	}
+	if req.HouseholdSize != nil {
+		user.HouseholdSize = *req.HouseholdSize
+	}
 
 	if err := h.userRepo.Update(user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
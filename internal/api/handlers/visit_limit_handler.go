@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/byte4bite/byte4bite/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// VisitLimitHandler handles visit limit policy and eligibility endpoints
+type VisitLimitHandler struct {
+	visitLimitService *services.VisitLimitService
+}
+
+// NewVisitLimitHandler creates a new visit limit handler
+func NewVisitLimitHandler(visitLimitService *services.VisitLimitService) *VisitLimitHandler {
+	return &VisitLimitHandler{
+		visitLimitService: visitLimitService,
+	}
+}
+
+// GetEligibility reports whether the current user may order from a pantry
+// GET /api/v1/users/eligibility?pantry_id=
+func (h *VisitLimitHandler) GetEligibility(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	pantryID, err := uuid.Parse(c.Query("pantry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	eligibility, err := h.visitLimitService.CheckEligibility(userID.(uuid.UUID), pantryID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, eligibility)
+}
+
+// ListPolicies lists the visit limit policies of a pantry
+// GET /api/v1/admin/pantries/:id/visit-limits
+func (h *VisitLimitHandler) ListPolicies(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	policies, err := h.visitLimitService.GetPolicies(pantryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get visit limits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  policies,
+		"count": len(policies),
+	})
+}
+
+// CreatePolicy creates a visit limit policy for a pantry
+// POST /api/v1/admin/pantries/:id/visit-limits
+func (h *VisitLimitHandler) CreatePolicy(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	var req services.CreateVisitLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.visitLimitService.CreatePolicy(pantryID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// UpdatePolicy updates a visit limit policy
+// PUT /api/v1/admin/pantries/:id/visit-limits/:limit_id
+func (h *VisitLimitHandler) UpdatePolicy(c *gin.Context) {
+	policyID, err := uuid.Parse(c.Param("limit_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid visit limit ID"})
+		return
+	}
+
+	var req services.UpdateVisitLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.visitLimitService.UpdatePolicy(policyID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy deletes a visit limit policy
+// DELETE /api/v1/admin/pantries/:id/visit-limits/:limit_id
+func (h *VisitLimitHandler) DeletePolicy(c *gin.Context) {
+	policyID, err := uuid.Parse(c.Param("limit_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid visit limit ID"})
+		return
+	}
+
+	if err := h.visitLimitService.DeletePolicy(policyID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "visit limit deleted successfully"})
+}
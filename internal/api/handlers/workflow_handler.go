@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WorkflowHandler handles order workflow configuration endpoints
+type WorkflowHandler struct {
+	workflowService *services.WorkflowService
+}
+
+// NewWorkflowHandler creates a new workflow handler
+func NewWorkflowHandler(workflowService *services.WorkflowService) *WorkflowHandler {
+	return &WorkflowHandler{
+		workflowService: workflowService,
+	}
+}
+
+// GetWorkflow returns the order workflow in effect for a pantry
+// GET /api/v1/admin/pantries/:id/workflow?fulfillment_type=pickup|delivery
+func (h *WorkflowHandler) GetWorkflow(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	workflow, err := h.workflowService.GetWorkflow(pantryID, fulfillmentType(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+// SetWorkflow defines a custom order workflow for a pantry
+// PUT /api/v1/admin/pantries/:id/workflow?fulfillment_type=pickup|delivery
+func (h *WorkflowHandler) SetWorkflow(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	var req services.SetWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workflow, err := h.workflowService.SetWorkflow(pantryID, fulfillmentType(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+// ResetWorkflow reverts a pantry to the default order workflow
+// DELETE /api/v1/admin/pantries/:id/workflow?fulfillment_type=pickup|delivery
+func (h *WorkflowHandler) ResetWorkflow(c *gin.Context) {
+	pantryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pantry ID"})
+		return
+	}
+
+	if err := h.workflowService.ResetWorkflow(pantryID, fulfillmentType(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "workflow reset to default"})
+}
+
+// fulfillmentType reads the fulfillment_type query parameter, defaulting to pickup
+func fulfillmentType(c *gin.Context) models.FulfillmentType {
+	return models.FulfillmentType(c.DefaultQuery("fulfillment_type", string(models.FulfillmentPickup)))
+}
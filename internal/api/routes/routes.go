@@ -2,6 +2,7 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/byte4bite/byte4bite/internal/api/handlers"
 	"github.com/byte4bite/byte4bite/internal/api/middleware"
@@ -26,16 +27,38 @@ func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	cartRepo := repositories.NewCartRepository(db)
 	orderRepo := repositories.NewOrderRepository(db)
 	donationRepo := repositories.NewDonationRepository(db)
+	slotRepo := repositories.NewPickupSlotRepository(db)
+	orderEventRepo := repositories.NewOrderEventRepository(db)
+	workflowRepo := repositories.NewWorkflowRepository(db)
+	visitLimitRepo := repositories.NewVisitLimitRepository(db)
+	stockHoldRepo := repositories.NewStockHoldRepository(db)
+	stockMovementRepo := repositories.NewStockMovementRepository(db)
+	itemLotRepo := repositories.NewItemLotRepository(db)
+	catalogRepo := repositories.NewCatalogRepository(db)
+	txManager := repositories.NewTxManager(db)
 
 	// Initialize services
 	jwtService := auth.NewJWTService(cfg.JWT.Secret, cfg.JWT.ExpiryHours)
+	pickupTokenService := auth.NewPickupTokenService(cfg.Pickup.TokenSecret)
+	staffService := services.NewStaffService(userRepo, pantryRepo, orderRepo, orderEventRepo, workflowRepo)
+	stockHoldService := services.NewStockHoldService(stockHoldRepo, itemRepo, txManager, time.Duration(cfg.Cart.HoldMinutes)*time.Minute)
 	authService := services.NewAuthService(userRepo, jwtService)
-	pantryService := services.NewPantryService(pantryRepo)
+	pantryService := services.NewPantryService(pantryRepo, cartRepo, txManager, stockHoldService)
 	categoryService := services.NewCategoryService(categoryRepo)
-	itemService := services.NewItemService(itemRepo)
-	cartService := services.NewCartService(cartRepo, itemRepo)
-	orderService := services.NewOrderService(orderRepo, itemRepo)
-	donationService := services.NewDonationService(donationRepo, pantryRepo)
+	stockService := services.NewStockService(itemRepo, itemLotRepo, stockMovementRepo, txManager)
+	itemService := services.NewItemService(itemRepo, stockMovementRepo, itemLotRepo, categoryRepo, catalogRepo, stockService, txManager)
+	catalogService := services.NewCatalogService(catalogRepo)
+	cartService := services.NewCartService(cartRepo, itemRepo, categoryRepo, orderRepo, userRepo, slotRepo, orderEventRepo, workflowRepo, visitLimitRepo, stockService, txManager, staffService, stockHoldService)
+	orderService := services.NewOrderService(orderRepo, cartRepo, itemRepo, userRepo, slotRepo, orderEventRepo, workflowRepo, stockService, txManager, staffService)
+	donationService := services.NewDonationService(donationRepo, pantryRepo, itemRepo, categoryRepo, stockService, txManager)
+	slotService := services.NewPickupSlotService(slotRepo, pantryRepo, orderRepo, workflowRepo, txManager)
+	workflowService := services.NewWorkflowService(workflowRepo, pantryRepo, orderRepo, txManager)
+	deliveryService := services.NewDeliveryService(orderRepo, userRepo, pantryRepo, orderEventRepo, workflowRepo, txManager, staffService)
+	visitLimitService := services.NewVisitLimitService(visitLimitRepo, pantryRepo, orderRepo)
+	pickListService := services.NewPickListService(orderRepo, pantryRepo)
+	verificationService := services.NewPickupVerificationService(orderRepo, orderService, pickupTokenService, workflowRepo)
+	reportService := services.NewReportService(orderRepo)
+	assistedOrderService := services.NewAssistedOrderService(cartRepo, itemRepo, orderRepo, userRepo, pantryRepo, slotRepo, orderEventRepo, workflowRepo, visitLimitRepo, stockService, txManager, staffService)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
@@ -43,9 +66,19 @@ func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	pantryHandler := handlers.NewPantryHandler(pantryService)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
 	itemHandler := handlers.NewItemHandler(itemService)
-	cartHandler := handlers.NewCartHandler(cartService, orderRepo)
+	catalogHandler := handlers.NewCatalogHandler(catalogService)
+	cartHandler := handlers.NewCartHandler(cartService)
 	orderHandler := handlers.NewOrderHandler(orderService)
 	donationHandler := handlers.NewDonationHandler(donationService)
+	slotHandler := handlers.NewPickupSlotHandler(slotService)
+	workflowHandler := handlers.NewWorkflowHandler(workflowService)
+	deliveryHandler := handlers.NewDeliveryHandler(deliveryService)
+	visitLimitHandler := handlers.NewVisitLimitHandler(visitLimitService)
+	pickListHandler := handlers.NewPickListHandler(pickListService)
+	verificationHandler := handlers.NewPickupVerificationHandler(verificationService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	assistedOrderHandler := handlers.NewAssistedOrderHandler(assistedOrderService)
+	staffHandler := handlers.NewStaffHandler(staffService)
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -66,6 +99,7 @@ func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			pantries.GET("/by-city", pantryHandler.GetPantriesByCity)
 			pantries.GET("/by-zip", pantryHandler.GetPantriesByZipCode)
 			pantries.GET("/:id", pantryHandler.GetPantry)
+			pantries.GET("/:id/pickup-slots", slotHandler.GetPickupSlots)
 		}
 
 		// Public donation route (no authentication required)
@@ -93,6 +127,7 @@ func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 				users.GET("/profile", userHandler.GetProfile)
 				users.PUT("/profile", userHandler.UpdateProfile)
 				users.PUT("/password", userHandler.UpdatePassword)
+				users.GET("/eligibility", visitLimitHandler.GetEligibility)
 			}
 
 			// Items routes - public browsing for authenticated users
@@ -102,7 +137,9 @@ func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			// Cart routes
 			carts := protected.Group("/carts")
 			{
+				carts.GET("", cartHandler.GetActiveCarts)
 				carts.GET("/current", cartHandler.GetCurrentCart)
+				carts.GET("/current/validate", cartHandler.ValidateCart)
 				carts.POST("/items", cartHandler.AddItem)
 				carts.PUT("/items/:id", cartHandler.UpdateItemQuantity)
 				carts.DELETE("/items/:id", cartHandler.RemoveItem)
@@ -115,7 +152,13 @@ func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			{
 				orders.GET("", orderHandler.GetOrders)
 				orders.GET("/:id", orderHandler.GetOrder)
+				orders.GET("/:id/history", orderHandler.GetOrderHistory)
+				orders.GET("/:id/pickup-pass", verificationHandler.GetPickupPass)
+				orders.PUT("/:id/items", orderHandler.UpdateOrderItems)
+				orders.POST("/:id/reorder", cartHandler.Reorder)
 				orders.DELETE("/:id", orderHandler.CancelOrder)
+				orders.PUT("/:id/pickup-slot", slotHandler.ReschedulePickup)
+				orders.DELETE("/:id/pickup-slot", slotHandler.CancelPickup)
 			}
 		}
 
@@ -146,22 +189,62 @@ func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 				items.GET("", itemHandler.ListItems)
 				items.POST("", itemHandler.CreateItem)
 				items.GET("/low-stock", itemHandler.GetLowStockItems)
+				items.GET("/expiring", itemHandler.GetExpiringLots)
+				items.GET("/by-barcode/:code", itemHandler.GetItemByBarcode)
+				items.POST("/scan", itemHandler.ScanIntake)
+				items.POST("/import", itemHandler.ImportItems)
+				items.GET("/export", itemHandler.ExportItems)
+				items.POST("/lots/:id/discard", itemHandler.DiscardLot)
 				items.GET("/:id", itemHandler.GetItem)
 				items.PUT("/:id", itemHandler.UpdateItem)
 				items.DELETE("/:id", itemHandler.DeleteItem)
 				items.PATCH("/:id/quantity", itemHandler.UpdateItemQuantity)
+				items.GET("/:id/movements", itemHandler.GetMovements)
+				items.POST("/:id/movements", itemHandler.RecordMovement)
+				items.GET("/:id/lots", itemHandler.GetLots)
+			}
+
+			// Product catalog used to prefill scanned items
+			catalog := admin.Group("/catalog")
+			{
+				catalog.POST("/import", catalogHandler.ImportCatalog)
+				catalog.GET("/:code", catalogHandler.GetProduct)
 			}
 
 			// Admin order management routes
 			adminOrders := admin.Group("/orders")
 			{
 				adminOrders.GET("", orderHandler.GetOrders)
+				adminOrders.POST("", assistedOrderHandler.CreateClientOrder)
+				adminOrders.POST("/walk-in", assistedOrderHandler.CreateWalkInOrder)
+				adminOrders.POST("/bulk", orderHandler.BulkUpdateOrders)
+				adminOrders.POST("/verify", verificationHandler.VerifyPickup)
 				adminOrders.GET("/:id", orderHandler.GetOrder)
+				adminOrders.GET("/:id/history", orderHandler.GetOrderHistory)
+				adminOrders.GET("/:id/packing-slip", pickListHandler.GetPackingSlip)
 				adminOrders.PUT("/:id/status", orderHandler.UpdateOrderStatus)
 				adminOrders.PUT("/:id/assign", orderHandler.AssignStaff)
+				adminOrders.PUT("/:id/driver", deliveryHandler.AssignDriver)
+				adminOrders.PUT("/:id/items/:line_id", orderHandler.AdjustOrderLine)
+				adminOrders.DELETE("/:id/items/:line_id", orderHandler.RemoveOrderLine)
+				adminOrders.POST("/:id/items/:line_id/substitute", orderHandler.SubstituteOrderLine)
 				adminOrders.DELETE("/:id", orderHandler.CancelOrder)
+				adminOrders.PUT("/:id/pickup-slot", slotHandler.ReschedulePickup)
+				adminOrders.DELETE("/:id/pickup-slot", slotHandler.CancelPickup)
 			}
 
+			// Admin pick list route
+			admin.GET("/pick-lists", pickListHandler.GetPickList)
+
+			// Admin report routes
+			admin.GET("/reports/distribution", reportHandler.GetDistributionReport)
+
+			// Admin staff routes
+			admin.GET("/staff/workload", staffHandler.GetWorkload)
+
+			// Admin delivery routes
+			admin.GET("/deliveries/run-sheet", deliveryHandler.GetRunSheet)
+
 			// Admin pantry management routes
 			adminPantries := admin.Group("/pantries")
 			{
@@ -169,6 +252,17 @@ func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 				adminPantries.PUT("/:id", pantryHandler.UpdatePantry)
 				adminPantries.DELETE("/:id", pantryHandler.DeletePantry)
 				adminPantries.PATCH("/:id/toggle", pantryHandler.TogglePantryStatus)
+				adminPantries.GET("/:id/slot-templates", slotHandler.ListTemplates)
+				adminPantries.POST("/:id/slot-templates", slotHandler.CreateTemplate)
+				adminPantries.PUT("/:id/slot-templates/:template_id", slotHandler.UpdateTemplate)
+				adminPantries.DELETE("/:id/slot-templates/:template_id", slotHandler.DeleteTemplate)
+				adminPantries.GET("/:id/workflow", workflowHandler.GetWorkflow)
+				adminPantries.PUT("/:id/workflow", workflowHandler.SetWorkflow)
+				adminPantries.DELETE("/:id/workflow", workflowHandler.ResetWorkflow)
+				adminPantries.GET("/:id/visit-limits", visitLimitHandler.ListPolicies)
+				adminPantries.POST("/:id/visit-limits", visitLimitHandler.CreatePolicy)
+				adminPantries.PUT("/:id/visit-limits/:limit_id", visitLimitHandler.UpdatePolicy)
+				adminPantries.DELETE("/:id/visit-limits/:limit_id", visitLimitHandler.DeletePolicy)
 			}
 
 			// Admin donation management routes
@@ -182,6 +276,8 @@ func Setup(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 				adminDonations.PUT("/:id", donationHandler.UpdateDonation)
 				adminDonations.DELETE("/:id", donationHandler.DeleteDonation)
 				adminDonations.PATCH("/:id/receipt", donationHandler.MarkReceiptSent)
+				adminDonations.PUT("/:id/items", donationHandler.SetDonationItems)
+				adminDonations.POST("/:id/receive", donationHandler.ReceiveDonation)
 			}
 		}
 	}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPickupTokenRoundTrip(t *testing.T) {
+	s := NewPickupTokenService("test-secret")
+	orderID := uuid.New()
+
+	gotID, gotCode, err := s.Verify(s.Sign(orderID, "ACD346"))
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	}
+	if gotID != orderID || gotCode != "ACD346" {
+		t.Errorf("Verify = %s, %q, want %s, %q", gotID, gotCode, orderID, "ACD346")
+	}
+}
+
+func TestPickupTokenInvalid(t *testing.T) {
+	s := NewPickupTokenService("test-secret")
+	orderID := uuid.New()
+	token := s.Sign(orderID, "ACD346")
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// A payload for another order carrying the original signature
+	otherPayload := base64.RawURLEncoding.EncodeToString([]byte(uuid.New().String() + ":ACD346"))
+	// A payload for the same order with a different code
+	otherCode := base64.RawURLEncoding.EncodeToString([]byte(orderID.String() + ":XYZ789"))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", payload},
+		{"empty signature", payload + "."},
+		{"swapped order", otherPayload + "." + signature},
+		{"swapped code", otherCode + "." + signature},
+		{"flipped signature character", payload + "." + flip(signature)},
+		{"truncated signature", payload + "." + signature[:len(signature)-1]},
+		{"foreign secret", NewPickupTokenService("other-secret").Sign(orderID, "ACD346")},
+		{"empty secret", NewPickupTokenService("").Sign(orderID, "ACD346")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if id, code, err := s.Verify(tt.token); err != ErrInvalidPickupToken {
+				t.Errorf("Verify(%q) = %s, %q, %v, want ErrInvalidPickupToken", tt.token, id, code, err)
+			}
+		})
+	}
+}
+
+func TestPickupTokenMalformedPayload(t *testing.T) {
+	s := NewPickupTokenService("test-secret")
+
+	// Correctly signed payloads that do not carry an order ID and code
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"not base64", "!!!"},
+		{"no separator", base64.RawURLEncoding.EncodeToString([]byte("ACD346"))},
+		{"bad order ID", base64.RawURLEncoding.EncodeToString([]byte("not-a-uuid:ACD346"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := tt.payload + "." + s.signature(tt.payload)
+			if _, _, err := s.Verify(token); err != ErrInvalidPickupToken {
+				t.Errorf("Verify(%q) returned %v, want ErrInvalidPickupToken", token, err)
+			}
+		})
+	}
+}
+
+// flip changes the first character of s
+func flip(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
@@ -0,0 +1,58 @@
+// Package barcode validates the retail barcodes printed on packaged food:
+// UPC-A, EAN-8, EAN-13 and GTIN-14, all of which end in a mod-10 check digit.
+// Codes are stored in one canonical form, the zero-padded GTIN-14, so the same
+// product matches whichever way a scanner reports it.
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// Length is the length of a normalized code
+const Length = 14
+
+// ErrInvalid is returned for codes that are not a valid UPC or EAN
+var ErrInvalid = errors.New("barcode must be a UPC-A, EAN-8, EAN-13 or GTIN-14 with a valid check digit")
+
+// Normalize strips spaces and dashes from a scanned or typed code, checks its
+// length and check digit and returns it as a GTIN-14. Padding with zeros keeps
+// the check digit valid, so a UPC-A and the EAN-13 a scanner reports for it
+// normalize to the same code.
+func Normalize(code string) (string, error) {
+	code = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, code)
+
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return "", ErrInvalid
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", ErrInvalid
+		}
+	}
+	if checkDigit(code[:len(code)-1]) != code[len(code)-1] {
+		return "", ErrInvalid
+	}
+	return strings.Repeat("0", Length-len(code)) + code, nil
+}
+
+// checkDigit computes the GS1 check digit of the digits before it: weights
+// alternate 3 and 1 starting from the rightmost digit
+func checkDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,54 @@
+package barcode
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"UPC-A", "036000291452", "00036000291452"},
+		{"EAN-13 form of the same UPC-A", "0036000291452", "00036000291452"},
+		{"EAN-13", "4006381333931", "04006381333931"},
+		{"EAN-8", "96385074", "00000096385074"},
+		{"GTIN-14", "10036000291459", "10036000291459"},
+		{"spaces and dashes", "0 36000-29145 2", "00036000291452"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.code)
+			if err != nil {
+				t.Fatalf("Normalize(%q) returned error: %v", tt.code, err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+			if len(got) != Length {
+				t.Errorf("Normalize(%q) has length %d, want %d", tt.code, len(got), Length)
+			}
+		})
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"empty", ""},
+		{"wrong check digit", "036000291453"},
+		{"wrong EAN-13 check digit", "4006381333932"},
+		{"too short", "1234567"},
+		{"unsupported length", "12345678901"},
+		{"too long", "100360002914590"},
+		{"letters", "03600029145A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Normalize(tt.code); err != ErrInvalid {
+				t.Errorf("Normalize(%q) = %q, %v, want ErrInvalid", tt.code, got, err)
+			}
+		})
+	}
+}
@@ -13,6 +13,9 @@ type Config struct {
 	JWT      JWTConfig
 	Email    EmailConfig
 	SMS      SMSConfig
+	Pickup   PickupConfig
+	Cart     CartConfig
+	Jobs     JobsConfig
 }
 
 // ServerConfig holds server-related configuration
@@ -56,6 +59,24 @@ type SMSConfig struct {
 	FromNumber string
 }
 
+// PickupConfig holds pickup verification configuration
+type PickupConfig struct {
+	TokenSecret string // Signs QR pickup tokens; defaults to the JWT secret
+}
+
+// CartConfig holds cart configuration
+type CartConfig struct {
+	HoldMinutes int // How long items added to a cart stay reserved
+}
+
+// JobsConfig holds background job configuration
+type JobsConfig struct {
+	Enabled                  bool
+	NoShowIntervalMinutes    int
+	HoldSweepIntervalMinutes int
+	LotExpiryIntervalMinutes int
+}
+
 // Load reads configuration from environment variables
 func Load() (*Config, error) {
 	cfg := &Config{
@@ -92,11 +113,43 @@ func Load() (*Config, error) {
 		},
 	}
 
+	cfg.Pickup = PickupConfig{
+		TokenSecret: getEnv("PICKUP_TOKEN_SECRET", cfg.JWT.Secret),
+	}
+
+	cfg.Cart = CartConfig{
+		HoldMinutes: getEnvAsInt("CART_HOLD_MINUTES", 30),
+	}
+
+	cfg.Jobs = JobsConfig{
+		Enabled:                  getEnvAsBool("JOBS_ENABLED", true),
+		NoShowIntervalMinutes:    getEnvAsInt("NO_SHOW_SWEEP_MINUTES", 5),
+		HoldSweepIntervalMinutes: getEnvAsInt("HOLD_SWEEP_MINUTES", 1),
+		LotExpiryIntervalMinutes: getEnvAsInt("LOT_EXPIRY_SWEEP_MINUTES", 15),
+	}
+
 	// Validate required fields
 	if cfg.JWT.Secret == "your-secret-key-change-in-production" && cfg.Server.Environment == "production" {
 		return nil, fmt.Errorf("JWT_SECRET must be set in production environment")
 	}
 
+	// Job intervals drive tickers, which cannot run at zero or negative
+	// intervals, and holds that expire on creation would disable reservations
+	intervals := []struct {
+		env     string
+		minutes int
+	}{
+		{"CART_HOLD_MINUTES", cfg.Cart.HoldMinutes},
+		{"NO_SHOW_SWEEP_MINUTES", cfg.Jobs.NoShowIntervalMinutes},
+		{"HOLD_SWEEP_MINUTES", cfg.Jobs.HoldSweepIntervalMinutes},
+		{"LOT_EXPIRY_SWEEP_MINUTES", cfg.Jobs.LotExpiryIntervalMinutes},
+	}
+	for _, interval := range intervals {
+		if interval.minutes <= 0 {
+			return nil, fmt.Errorf("%s must be greater than 0, got %d", interval.env, interval.minutes)
+		}
+	}
+
 	return cfg, nil
 }
 
@@ -33,15 +33,30 @@ func Connect(cfg config.DatabaseConfig) (*gorm.DB, error) {
 func RunMigrations(db *gorm.DB) error {
 	log.Println("Running database migrations...")
 
+	// Lines of orders placed before requested quantities were tracked are backfilled below
+	backfillRequested := !db.Migrator().HasColumn(&models.CartItem{}, "requested_quantity")
+
 	err := db.AutoMigrate(
 		&models.User{},
 		&models.Pantry{},
 		&models.Category{},
 		&models.Item{},
+		&models.ItemLot{},
+		&models.CatalogProduct{},
 		&models.Cart{},
 		&models.CartItem{},
+		&models.StockHold{},
+		&models.StockMovement{},
 		&models.Order{},
+		&models.PickupSlotTemplate{},
+		&models.PickupSlot{},
+		&models.OrderEvent{},
+		&models.OrderWorkflow{},
+		&models.WorkflowState{},
+		&models.WorkflowTransition{},
+		&models.VisitLimitPolicy{},
 		&models.Donation{},
+		&models.DonationItem{},
 		&models.Notification{},
 	)
 
@@ -49,6 +64,115 @@ func RunMigrations(db *gorm.DB) error {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	// Workflows used to be unique per pantry; they are now unique per pantry and fulfillment type
+	if db.Migrator().HasIndex(&models.OrderWorkflow{}, "idx_order_workflows_pantry_id") {
+		if err := db.Migrator().DropIndex(&models.OrderWorkflow{}, "idx_order_workflows_pantry_id"); err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+	}
+
+	// Carts used to fall back to a placeholder pantry and accept items of any
+	// pantry; such carts cannot be checked out and are cancelled. Their stock
+	// holds are left to expire.
+	err = db.Model(&models.Cart{}).
+		Where("status = ?", models.CartStatusActive).
+		Where("pantry_id NOT IN (SELECT id FROM pantries) OR id IN (SELECT cart_items.cart_id FROM cart_items JOIN items ON items.id = cart_items.item_id WHERE items.pantry_id <> carts.pantry_id)").
+		Update("status", models.CartStatusCancelled).Error
+	if err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	// Items that predate the stock ledger get an opening balance so their
+	// quantity matches the sum of their movements
+	err = db.Exec(`INSERT INTO stock_movements (id, item_id, pantry_id, type, quantity, balance_after, reason, created_at)
+		SELECT gen_random_uuid(), items.id, items.pantry_id, ?, items.quantity, items.quantity, 'opening balance', NOW()
+		FROM items
+		WHERE items.quantity <> 0 AND NOT EXISTS (SELECT 1 FROM stock_movements WHERE stock_movements.item_id = items.id)`,
+		models.StockAdjust).Error
+	if err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	// Stock that predates lot tracking goes into one undated lot per item
+	err = db.Exec(`INSERT INTO item_lots (id, item_id, pantry_id, quantity, received_at, source, expired, created_at, updated_at)
+		SELECT gen_random_uuid(), items.id, items.pantry_id, items.quantity, items.created_at, 'opening balance', false, NOW(), NOW()
+		FROM items
+		WHERE items.quantity > 0 AND NOT EXISTS (SELECT 1 FROM item_lots WHERE item_lots.item_id = items.id)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	if backfillRequested {
+		err := db.Model(&models.CartItem{}).Where("1 = 1").
+			UpdateColumn("requested_quantity", gorm.Expr("quantity")).Error
+		if err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+	}
+
+	if err := seedDefaultWorkflows(db); err != nil {
+		return fmt.Errorf("failed to seed default workflows: %w", err)
+	}
+	if err := syncDefaultWorkflows(db); err != nil {
+		return fmt.Errorf("failed to update default workflows: %w", err)
+	}
+
 	log.Println("Database migrations completed successfully")
 	return nil
 }
+
+// seedDefaultWorkflows creates the default pickup and delivery workflows if they do not exist yet
+func seedDefaultWorkflows(db *gorm.DB) error {
+	for _, workflow := range []*models.OrderWorkflow{models.DefaultOrderWorkflow(), models.DefaultDeliveryWorkflow()} {
+		var count int64
+		err := db.Model(&models.OrderWorkflow{}).
+			Where("pantry_id IS NULL AND fulfillment_type = ?", workflow.FulfillmentType).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		log.Printf("Seeding default %s workflow...", workflow.FulfillmentType)
+		if err := db.Create(workflow).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncDefaultWorkflows adds states and transitions introduced in newer releases
+// to default workflows seeded earlier. Existing rows are left untouched.
+func syncDefaultWorkflows(db *gorm.DB) error {
+	for _, defaults := range []*models.OrderWorkflow{models.DefaultOrderWorkflow(), models.DefaultDeliveryWorkflow()} {
+		var stored models.OrderWorkflow
+		err := db.Preload("States").Preload("Transitions").
+			Where("pantry_id IS NULL AND fulfillment_type = ?", defaults.FulfillmentType).
+			First(&stored).Error
+		if err != nil {
+			return err
+		}
+
+		for _, state := range defaults.States {
+			if stored.FindState(state.Status) != nil {
+				continue
+			}
+			state.WorkflowID = stored.ID
+			if err := db.Create(&state).Error; err != nil {
+				return err
+			}
+		}
+		for _, transition := range defaults.Transitions {
+			if stored.FindTransition(transition.FromStatus, transition.ToStatus) != nil {
+				continue
+			}
+			transition.WorkflowID = stored.ID
+			if err := db.Create(&transition).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Package jobs runs periodic background work inside the server process. Every
+// job must be safe to run on several replicas at once.
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/config"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/byte4bite/byte4bite/internal/services"
+	"gorm.io/gorm"
+)
+
+// noShowBatchSize is how many orders one no-show sweep expires per transaction
+const noShowBatchSize = 50
+
+// holdBatchSize is how many stock holds one sweep releases per transaction
+const holdBatchSize = 200
+
+// lotBatchSize is how many lapsed lots one sweep flags per transaction
+const lotBatchSize = 200
+
+// Start launches the background jobs; they stop when ctx is cancelled
+func Start(ctx context.Context, db *gorm.DB, cfg *config.Config) {
+	if !cfg.Jobs.Enabled {
+		log.Println("Background jobs disabled")
+		return
+	}
+
+	// Initialize repositories
+	userRepo := repositories.NewUserRepository(db)
+	pantryRepo := repositories.NewPantryRepository(db)
+	itemRepo := repositories.NewItemRepository(db)
+	cartRepo := repositories.NewCartRepository(db)
+	orderRepo := repositories.NewOrderRepository(db)
+	slotRepo := repositories.NewPickupSlotRepository(db)
+	orderEventRepo := repositories.NewOrderEventRepository(db)
+	workflowRepo := repositories.NewWorkflowRepository(db)
+	stockHoldRepo := repositories.NewStockHoldRepository(db)
+	stockMovementRepo := repositories.NewStockMovementRepository(db)
+	itemLotRepo := repositories.NewItemLotRepository(db)
+	txManager := repositories.NewTxManager(db)
+
+	// Initialize services
+	staffService := services.NewStaffService(userRepo, pantryRepo, orderRepo, orderEventRepo, workflowRepo)
+	stockService := services.NewStockService(itemRepo, itemLotRepo, stockMovementRepo, txManager)
+	orderService := services.NewOrderService(orderRepo, cartRepo, itemRepo, userRepo, slotRepo, orderEventRepo, workflowRepo, stockService, txManager, staffService)
+	stockHoldService := services.NewStockHoldService(stockHoldRepo, itemRepo, txManager, time.Duration(cfg.Cart.HoldMinutes)*time.Minute)
+
+	go every(ctx, "no-show expiry", time.Duration(cfg.Jobs.NoShowIntervalMinutes)*time.Minute, func() error {
+		return expireNoShows(orderService)
+	})
+	go every(ctx, "stock hold expiry", time.Duration(cfg.Jobs.HoldSweepIntervalMinutes)*time.Minute, func() error {
+		return expireHolds(stockHoldService)
+	})
+	go every(ctx, "lot expiry", time.Duration(cfg.Jobs.LotExpiryIntervalMinutes)*time.Minute, func() error {
+		return expireLots(stockService)
+	})
+}
+
+// expireNoShows expires overdue ready orders batch by batch until none are left
+func expireNoShows(orderService *services.OrderService) error {
+	for {
+		expired, err := orderService.ExpireNoShows(noShowBatchSize)
+		if err != nil {
+			return err
+		}
+		if expired > 0 {
+			log.Printf("Expired %d no-show orders", expired)
+		}
+		if expired < noShowBatchSize {
+			return nil
+		}
+	}
+}
+
+// expireHolds releases expired cart stock holds batch by batch until none are left
+func expireHolds(stockHoldService *services.StockHoldService) error {
+	for {
+		released, err := stockHoldService.ExpireHolds(holdBatchSize)
+		if err != nil {
+			return err
+		}
+		if released > 0 {
+			log.Printf("Released %d expired stock holds", released)
+		}
+		if released < holdBatchSize {
+			return nil
+		}
+	}
+}
+
+// expireLots flags lots past their expiration date batch by batch until none are left
+func expireLots(stockService *services.StockService) error {
+	for {
+		expired, err := stockService.ExpireLots(lotBatchSize)
+		if err != nil {
+			return err
+		}
+		if expired > 0 {
+			log.Printf("Expired %d item lots", expired)
+		}
+		if expired < lotBatchSize {
+			return nil
+		}
+	}
+}
+
+// every runs fn immediately and then at each interval until ctx is cancelled
+func every(ctx context.Context, name string, interval time.Duration, fn func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(); err != nil {
+			log.Printf("Job %s failed: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
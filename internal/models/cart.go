@@ -19,8 +19,8 @@ const (
 // Cart represents a user's shopping cart
 type Cart struct {
 	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID    uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
-	User      User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"user_id"` // Nil for walk-in orders
+	User      *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	PantryID  uuid.UUID  `gorm:"type:uuid;not null" json:"pantry_id"`
 	Pantry    Pantry     `gorm:"foreignKey:PantryID" json:"pantry,omitempty"`
 	Status    CartStatus `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
@@ -39,12 +39,19 @@ func (c *Cart) BeforeCreate(tx *gorm.DB) error {
 
 // CartItem represents an item in a shopping cart
 type CartItem struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	CartID    uuid.UUID `gorm:"type:uuid;not null" json:"cart_id"`
-	Cart      Cart      `gorm:"foreignKey:CartID" json:"cart,omitempty"`
-	ItemID    uuid.UUID `gorm:"type:uuid;not null" json:"item_id"`
-	Item      Item      `gorm:"foreignKey:ItemID" json:"item,omitempty"`
-	Quantity  int       `gorm:"not null;default:1" json:"quantity"`
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CartID   uuid.UUID `gorm:"type:uuid;not null" json:"cart_id"`
+	Cart     Cart      `gorm:"foreignKey:CartID" json:"cart,omitempty"`
+	ItemID   uuid.UUID `gorm:"type:uuid;not null" json:"item_id"`
+	Item     Item      `gorm:"foreignKey:ItemID" json:"item,omitempty"`
+	Quantity int       `gorm:"not null;default:1" json:"quantity"`
+
+	// Set at checkout. Quantity may later be adjusted or the item substituted by
+	// staff; these keep what the client originally asked for.
+	RequestedQuantity int        `gorm:"not null;default:0" json:"requested_quantity"`
+	SubstitutedForID  *uuid.UUID `gorm:"type:uuid" json:"substituted_for_id,omitempty"`
+	SubstitutedFor    *Item      `gorm:"foreignKey:SubstitutedForID" json:"substituted_for,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
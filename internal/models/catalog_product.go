@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// CatalogProduct is a product from an imported catalog file, used to prefill
+// new items when an unknown barcode is scanned
+type CatalogProduct struct {
+	Barcode      string    `gorm:"primary_key;size:14" json:"barcode"`
+	Name         string    `gorm:"not null" json:"name"`
+	Description  string    `json:"description"`
+	Brand        string    `json:"brand"`
+	Unit         string    `json:"unit"`
+	CategoryName string    `json:"category_name"` // Matched to a pantry category by name
+	ImageURL     string    `json:"image_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
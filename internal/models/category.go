@@ -14,6 +14,12 @@ type Category struct {
 	Description string     `json:"description"`
 	PantryID    uuid.UUID  `gorm:"type:uuid;not null" json:"pantry_id"`
 	Pantry      Pantry     `gorm:"foreignKey:PantryID" json:"pantry,omitempty"`
+
+	// Cap on the total quantity of the category's items per order; 0 means unlimited.
+	// When scaled, the cap applies per household member.
+	MaxPerOrder           int  `gorm:"not null;default:0" json:"max_per_order"`
+	ScaleLimitByHousehold bool `gorm:"not null;default:false" json:"scale_limit_by_household"`
+
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
@@ -19,8 +19,48 @@ type Donation struct {
 	Description  string    `gorm:"not null" json:"description"`
 	DonationDate time.Time `gorm:"not null" json:"donation_date"`
 	ReceiptSent  bool      `gorm:"default:false" json:"receipt_sent"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+
+	// In-kind donations list what was given; receiving them adds the lines to
+	// the pantry's stock
+	Items        []DonationItem `gorm:"foreignKey:DonationID" json:"items,omitempty"`
+	ReceivedAt   *time.Time     `json:"received_at"`
+	ReceivedByID *uuid.UUID     `gorm:"type:uuid" json:"received_by_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsReceived reports whether the donation's items were added to stock
+func (d *Donation) IsReceived() bool {
+	return d.ReceivedAt != nil
+}
+
+// DonationItem is one line of an in-kind donation. It names an existing item
+// or, when ItemID is empty, describes a new item that is created on receipt.
+type DonationItem struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DonationID uuid.UUID  `gorm:"type:uuid;not null;index" json:"donation_id"`
+	ItemID     *uuid.UUID `gorm:"type:uuid;index" json:"item_id"`
+	Item       *Item      `gorm:"foreignKey:ItemID" json:"item,omitempty"`
+	Name       string     `gorm:"not null" json:"name"`
+	CategoryID *uuid.UUID `gorm:"type:uuid" json:"category_id,omitempty"` // For new items
+	Quantity   int        `gorm:"not null" json:"quantity"`
+	Unit       string     `gorm:"not null" json:"unit"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+
+	// The lot the line was received into
+	LotID *uuid.UUID `gorm:"type:uuid" json:"lot_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (d *DonationItem) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
 }
 
 // BeforeCreate will set a UUID rather than numeric ID
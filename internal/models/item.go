@@ -14,13 +14,28 @@ type Item struct {
 	Description        string     `json:"description"`
 	CategoryID         uuid.UUID  `gorm:"type:uuid;not null" json:"category_id"`
 	Category           Category   `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
-	PantryID           uuid.UUID  `gorm:"type:uuid;not null" json:"pantry_id"`
+	PantryID           uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_items_pantry_barcode" json:"pantry_id"`
 	Pantry             Pantry     `gorm:"foreignKey:PantryID" json:"pantry,omitempty"`
 	Quantity           int        `gorm:"not null;default:0" json:"quantity"`
 	LowStockThreshold  int        `gorm:"not null;default:10" json:"low_stock_threshold"`
 	Unit               string     `gorm:"not null;default:'count'" json:"unit"` // e.g., "lb", "oz", "count"
 	ImageURL           string     `json:"image_url"`
 	IsAvailable        bool       `gorm:"default:true" json:"is_available"`
+
+	// UPC or EAN printed on the package as a zero-padded GTIN-14, unique within a pantry
+	Barcode *string `gorm:"size:14;uniqueIndex:idx_items_pantry_barcode" json:"barcode,omitempty"`
+
+	// Per-order limit; 0 means unlimited. When scaled, the limit applies per household member.
+	MaxPerOrder           int  `gorm:"not null;default:0" json:"max_per_order"`
+	ScaleLimitByHousehold bool `gorm:"not null;default:false" json:"scale_limit_by_household"`
+
+	// Stock reserved by items sitting in carts; see StockHold. Not available to other clients.
+	HeldQuantity      int `gorm:"not null;default:0" json:"held_quantity"`
+	AvailableQuantity int `gorm:"-" json:"available_quantity"`
+
+	// Stock in expired lots awaiting discard; counted in Quantity but never distributed
+	ExpiredQuantity int `gorm:"not null;default:0" json:"expired_quantity"`
+
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 }
@@ -33,6 +48,15 @@ func (i *Item) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// AfterFind computes the quantity that is neither held by carts nor expired
+func (i *Item) AfterFind(tx *gorm.DB) error {
+	i.AvailableQuantity = i.Quantity - i.HeldQuantity - i.ExpiredQuantity
+	if i.AvailableQuantity < 0 {
+		i.AvailableQuantity = 0
+	}
+	return nil
+}
+
 // IsLowStock checks if the item is below the low stock threshold
 func (i *Item) IsLowStock() bool {
 	return i.Quantity <= i.LowStockThreshold
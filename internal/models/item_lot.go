@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ItemLot is a batch of an item received together and sharing an expiration
+// date. An item's quantity is the sum of its lots' remaining quantities.
+type ItemLot struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ItemID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"item_id"`
+	Item       *Item      `gorm:"foreignKey:ItemID" json:"item,omitempty"`
+	PantryID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"pantry_id"`
+	Quantity   int        `gorm:"not null;default:0" json:"quantity"` // Remaining
+	ReceivedAt time.Time  `gorm:"not null" json:"received_at"`
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at"` // nil for non-perishables
+	Source     string     `json:"source"`
+	DonationID *uuid.UUID `gorm:"type:uuid;index" json:"donation_id,omitempty"`
+
+	// Set once the lot is past its expiration date; its remaining quantity is
+	// then counted in Item.ExpiredQuantity until discarded
+	Expired bool `gorm:"not null;default:false" json:"expired"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (l *ItemLot) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
@@ -16,6 +16,20 @@ const (
 	OrderStatusReady     OrderStatus = "ready"
 	OrderStatusPickedUp  OrderStatus = "picked_up"
 	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusExpired   OrderStatus = "expired" // Not picked up in time (no-show)
+
+	// Delivery-only statuses
+	OrderStatusOutForDelivery OrderStatus = "out_for_delivery"
+	OrderStatusDelivered      OrderStatus = "delivered"
+	OrderStatusFailedDelivery OrderStatus = "failed_delivery"
+)
+
+// FulfillmentType represents how an order reaches the client
+type FulfillmentType string
+
+const (
+	FulfillmentPickup   FulfillmentType = "pickup"
+	FulfillmentDelivery FulfillmentType = "delivery"
 )
 
 // Order represents a submitted cart order
@@ -23,19 +37,88 @@ type Order struct {
 	ID           uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	CartID       uuid.UUID    `gorm:"type:uuid;not null" json:"cart_id"`
 	Cart         Cart         `gorm:"foreignKey:CartID" json:"cart,omitempty"`
-	UserID       uuid.UUID    `gorm:"type:uuid;not null" json:"user_id"`
-	User         User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	PantryID     uuid.UUID    `gorm:"type:uuid;not null" json:"pantry_id"`
+	UserID       *uuid.UUID   `gorm:"type:uuid;index" json:"user_id"` // Nil for walk-in orders
+	User         *User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	PantryID     uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_orders_active_pickup_code" json:"pantry_id"`
 	Pantry       Pantry       `gorm:"foreignKey:PantryID" json:"pantry,omitempty"`
 	Status       OrderStatus  `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
 	Notes        string       `json:"notes"`
 	AssignedToID *uuid.UUID   `gorm:"type:uuid" json:"assigned_to_id"`
 	AssignedTo   *User        `gorm:"foreignKey:AssignedToID" json:"assigned_to,omitempty"`
+	PickupSlotID *uuid.UUID   `gorm:"type:uuid;index" json:"pickup_slot_id"`
+	PickupStart  *time.Time   `json:"pickup_start"`
+	PickupEnd    *time.Time   `json:"pickup_end"`
 	SubmittedAt  time.Time    `gorm:"not null" json:"submitted_at"`
 	ReadyAt      *time.Time   `json:"ready_at"`
 	PickedUpAt   *time.Time   `json:"picked_up_at"`
-	CreatedAt    time.Time    `json:"created_at"`
-	UpdatedAt    time.Time    `json:"updated_at"`
+
+	// Orders placed by staff record who created them. Walk-ins have no client
+	// account and are identified by name instead.
+	CreatedByID         *uuid.UUID `gorm:"type:uuid;index" json:"created_by_id,omitempty"`
+	CreatedBy           *User      `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	WalkInName          string     `json:"walk_in_name,omitempty"`
+	WalkInHouseholdSize int        `gorm:"not null;default:0" json:"walk_in_household_size,omitempty"`
+
+	// Pickup verification; the code stops working once used or when the order
+	// closes otherwise. Active codes are unique within a pantry.
+	PickupCode              string     `gorm:"type:varchar(8);uniqueIndex:idx_orders_active_pickup_code,where:pickup_code <> '' AND pickup_code_invalidated_at IS NULL" json:"pickup_code,omitempty"`
+	PickupCodeInvalidatedAt *time.Time `json:"pickup_code_invalidated_at,omitempty"`
+
+	// Delivery details, only set when FulfillmentType is delivery
+	FulfillmentType      FulfillmentType `gorm:"type:varchar(20);not null;default:'pickup'" json:"fulfillment_type"`
+	DeliveryAddress      string          `json:"delivery_address,omitempty"`
+	DeliveryCity         string          `json:"delivery_city,omitempty"`
+	DeliveryState        string          `json:"delivery_state,omitempty"`
+	DeliveryZipCode      string          `gorm:"index" json:"delivery_zip_code,omitempty"`
+	DeliveryWindowStart  *time.Time      `json:"delivery_window_start,omitempty"`
+	DeliveryWindowEnd    *time.Time      `json:"delivery_window_end,omitempty"`
+	DeliveryInstructions string          `json:"delivery_instructions,omitempty"`
+	DriverID             *uuid.UUID      `gorm:"type:uuid;index" json:"driver_id,omitempty"`
+	Driver               *User           `gorm:"foreignKey:DriverID" json:"driver,omitempty"`
+	OutForDeliveryAt     *time.Time      `json:"out_for_delivery_at,omitempty"`
+	DeliveredAt          *time.Time      `json:"delivered_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsWalkIn reports whether the order was placed for a visitor without an account
+func (o *Order) IsWalkIn() bool {
+	return o.UserID == nil
+}
+
+// IsOwnedBy reports whether the order belongs to the given client
+func (o *Order) IsOwnedBy(userID uuid.UUID) bool {
+	return o.UserID != nil && *o.UserID == userID
+}
+
+// ClientName returns the name of the client or walk-in visitor; the User must be loaded
+func (o *Order) ClientName() string {
+	if o.User == nil {
+		return o.WalkInName
+	}
+	return o.User.FirstName + " " + o.User.LastName
+}
+
+// ClientPhone returns the client's phone number, empty for walk-ins
+func (o *Order) ClientPhone() string {
+	if o.User == nil {
+		return ""
+	}
+	return o.User.Phone
+}
+
+// HouseholdSize returns the size of the household the order is for; the User must be loaded
+func (o *Order) HouseholdSize() int {
+	if o.User == nil {
+		return o.WalkInHouseholdSize
+	}
+	return o.User.HouseholdSize
+}
+
+// HasActivePickupCode reports whether the order's pickup code can still be used
+func (o *Order) HasActivePickupCode() bool {
+	return o.PickupCode != "" && o.PickupCodeInvalidatedAt == nil
 }
 
 // BeforeCreate will set a UUID rather than numeric ID
@@ -48,3 +131,17 @@ func (o *Order) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// SetTimestamp stamps one of the order's lifecycle timestamps by column name
+func (o *Order) SetTimestamp(field string, t time.Time) {
+	switch field {
+	case OrderTimestampReadyAt:
+		o.ReadyAt = &t
+	case OrderTimestampPickedUpAt:
+		o.PickedUpAt = &t
+	case OrderTimestampOutForDeliveryAt:
+		o.OutForDeliveryAt = &t
+	case OrderTimestampDeliveredAt:
+		o.DeliveredAt = &t
+	}
+}
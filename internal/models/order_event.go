@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderEventType represents the kind of change recorded for an order
+type OrderEventType string
+
+const (
+	OrderEventCreated        OrderEventType = "created"
+	OrderEventStatusChange   OrderEventType = "status_change"
+	OrderEventAssignment     OrderEventType = "assignment"
+	OrderEventCancellation   OrderEventType = "cancellation"
+	OrderEventDriver         OrderEventType = "driver_assignment"
+	OrderEventItemAdjustment OrderEventType = "item_adjustment"
+)
+
+// OrderEvent is an append-only history entry for an order
+type OrderEvent struct {
+	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID      uuid.UUID      `gorm:"type:uuid;not null;index" json:"order_id"`
+	Type         OrderEventType `gorm:"type:varchar(30);not null" json:"type"`
+	ActorID      *uuid.UUID     `gorm:"type:uuid" json:"actor_id"` // nil for system actions
+	Actor        *User          `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	FromStatus   OrderStatus    `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus     OrderStatus    `gorm:"type:varchar(20)" json:"to_status"`
+	AssignedToID *uuid.UUID     `gorm:"type:uuid" json:"assigned_to_id,omitempty"`
+	Reason       string         `json:"reason"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (e *OrderEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Order timestamps a workflow transition can stamp
+const (
+	OrderTimestampReadyAt          = "ready_at"
+	OrderTimestampPickedUpAt       = "picked_up_at"
+	OrderTimestampOutForDeliveryAt = "out_for_delivery_at"
+	OrderTimestampDeliveredAt      = "delivered_at"
+)
+
+// OrderWorkflow defines the states and allowed transitions of orders at a pantry
+// for one fulfillment type. Workflows without a pantry are the defaults used by
+// every pantry that has not defined its own.
+type OrderWorkflow struct {
+	ID              uuid.UUID            `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PantryID        *uuid.UUID           `gorm:"type:uuid;uniqueIndex:idx_workflow_pantry_fulfillment" json:"pantry_id"`
+	FulfillmentType FulfillmentType      `gorm:"type:varchar(20);not null;default:'pickup';uniqueIndex:idx_workflow_pantry_fulfillment" json:"fulfillment_type"`
+	Name            string               `gorm:"not null" json:"name"`
+	InitialStatus   OrderStatus          `gorm:"type:varchar(20);not null" json:"initial_status"`
+	States          []WorkflowState      `gorm:"foreignKey:WorkflowID" json:"states"`
+	Transitions     []WorkflowTransition `gorm:"foreignKey:WorkflowID" json:"transitions"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (w *OrderWorkflow) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// WorkflowState is a status an order can be in under a workflow
+type WorkflowState struct {
+	ID            uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	WorkflowID    uuid.UUID   `gorm:"type:uuid;not null;index" json:"workflow_id"`
+	Status        OrderStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Label         string      `json:"label"`
+	IsFinal       bool        `gorm:"default:false" json:"is_final"`
+	StaffEditable bool        `gorm:"default:false" json:"staff_editable"` // Staff may adjust and substitute order lines
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (s *WorkflowState) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// WorkflowTransition is an allowed move between two workflow states
+type WorkflowTransition struct {
+	ID                uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	WorkflowID        uuid.UUID   `gorm:"type:uuid;not null;index" json:"workflow_id"`
+	FromStatus        OrderStatus `gorm:"type:varchar(20);not null" json:"from_status"`
+	ToStatus          OrderStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	RestoresInventory bool        `gorm:"default:false" json:"restores_inventory"` // Order is abandoned: stock and pickup slot are released
+	StampField        string      `gorm:"type:varchar(30)" json:"stamp_field"`     // Order timestamp set when the transition happens
+	ClientAllowed     bool        `gorm:"default:false" json:"client_allowed"`     // Clients may trigger it on their own orders
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (t *WorkflowTransition) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+var workflowStatusPattern = regexp.MustCompile(`^[a-z][a-z_]{0,19}$`)
+
+// FindTransition returns the transition from one status to another, or nil if not allowed
+func (w *OrderWorkflow) FindTransition(from, to OrderStatus) *WorkflowTransition {
+	for i := range w.Transitions {
+		if w.Transitions[i].FromStatus == from && w.Transitions[i].ToStatus == to {
+			return &w.Transitions[i]
+		}
+	}
+	return nil
+}
+
+// FindStampingTransition returns a transition from a status into a final state
+// that sets the given order timestamp, or nil if there is none
+func (w *OrderWorkflow) FindStampingTransition(from OrderStatus, stampField string) *WorkflowTransition {
+	for i := range w.Transitions {
+		transition := &w.Transitions[i]
+		if transition.FromStatus != from || transition.StampField != stampField {
+			continue
+		}
+		if state := w.FindState(transition.ToStatus); state != nil && state.IsFinal {
+			return transition
+		}
+	}
+	return nil
+}
+
+// FindState returns the workflow state for a status, or nil if the workflow does not define it
+func (w *OrderWorkflow) FindState(status OrderStatus) *WorkflowState {
+	for i := range w.States {
+		if w.States[i].Status == status {
+			return &w.States[i]
+		}
+	}
+	return nil
+}
+
+// IsFinal reports whether orders in the given status are closed
+func (w *OrderWorkflow) IsFinal(status OrderStatus) bool {
+	state := w.FindState(status)
+	return state == nil || state.IsFinal
+}
+
+// IsStaffEditable reports whether staff may change the lines of orders in the given status
+func (w *OrderWorkflow) IsStaffEditable(status OrderStatus) bool {
+	state := w.FindState(status)
+	return state != nil && state.StaffEditable
+}
+
+// Validate checks that the workflow is internally consistent
+func (w *OrderWorkflow) Validate() error {
+	if len(w.States) == 0 {
+		return errors.New("workflow must define at least one state")
+	}
+
+	seen := make(map[OrderStatus]bool)
+	for _, state := range w.States {
+		if !workflowStatusPattern.MatchString(string(state.Status)) {
+			return fmt.Errorf("invalid status name %q: use lowercase letters and underscores", state.Status)
+		}
+		if seen[state.Status] {
+			return fmt.Errorf("duplicate state %q", state.Status)
+		}
+		if state.IsFinal && state.StaffEditable {
+			return fmt.Errorf("final state %q cannot be staff editable", state.Status)
+		}
+		seen[state.Status] = true
+	}
+
+	initial := w.FindState(w.InitialStatus)
+	if initial == nil {
+		return fmt.Errorf("initial status %q is not a workflow state", w.InitialStatus)
+	}
+	if initial.IsFinal {
+		return errors.New("initial status cannot be a final state")
+	}
+
+	for _, transition := range w.Transitions {
+		from := w.FindState(transition.FromStatus)
+		if from == nil {
+			return fmt.Errorf("transition from unknown state %q", transition.FromStatus)
+		}
+		if w.FindState(transition.ToStatus) == nil {
+			return fmt.Errorf("transition to unknown state %q", transition.ToStatus)
+		}
+		if from.IsFinal {
+			return fmt.Errorf("final state %q cannot have outgoing transitions", transition.FromStatus)
+		}
+		switch transition.StampField {
+		case "", OrderTimestampReadyAt, OrderTimestampPickedUpAt,
+			OrderTimestampOutForDeliveryAt, OrderTimestampDeliveredAt:
+		default:
+			return fmt.Errorf("unknown stamp field %q", transition.StampField)
+		}
+	}
+
+	return nil
+}
+
+// DefaultOrderWorkflow returns the standard pickup flow:
+// pending → preparing → ready → picked_up, cancellable until pickup
+func DefaultOrderWorkflow() *OrderWorkflow {
+	return &OrderWorkflow{
+		FulfillmentType: FulfillmentPickup,
+		Name:            "Default pickup",
+		InitialStatus:   OrderStatusPending,
+		States: []WorkflowState{
+			{Status: OrderStatusPending, Label: "Pending"},
+			{Status: OrderStatusPreparing, Label: "Preparing", StaffEditable: true},
+			{Status: OrderStatusReady, Label: "Ready for pickup"},
+			{Status: OrderStatusPickedUp, Label: "Picked up", IsFinal: true},
+			{Status: OrderStatusCancelled, Label: "Cancelled", IsFinal: true},
+			{Status: OrderStatusExpired, Label: "No-show", IsFinal: true},
+		},
+		Transitions: []WorkflowTransition{
+			{FromStatus: OrderStatusPending, ToStatus: OrderStatusPreparing},
+			{FromStatus: OrderStatusPending, ToStatus: OrderStatusCancelled, RestoresInventory: true, ClientAllowed: true},
+			{FromStatus: OrderStatusPreparing, ToStatus: OrderStatusReady, StampField: OrderTimestampReadyAt},
+			{FromStatus: OrderStatusPreparing, ToStatus: OrderStatusCancelled, RestoresInventory: true, ClientAllowed: true},
+			{FromStatus: OrderStatusReady, ToStatus: OrderStatusPickedUp, StampField: OrderTimestampPickedUpAt},
+			{FromStatus: OrderStatusReady, ToStatus: OrderStatusCancelled, RestoresInventory: true},
+			{FromStatus: OrderStatusReady, ToStatus: OrderStatusExpired, RestoresInventory: true},
+		},
+	}
+}
+
+// DefaultDeliveryWorkflow returns the standard home delivery flow:
+// pending → preparing → ready → out_for_delivery → delivered, where a failed
+// delivery can be retried or cancelled
+func DefaultDeliveryWorkflow() *OrderWorkflow {
+	return &OrderWorkflow{
+		FulfillmentType: FulfillmentDelivery,
+		Name:            "Default delivery",
+		InitialStatus:   OrderStatusPending,
+		States: []WorkflowState{
+			{Status: OrderStatusPending, Label: "Pending"},
+			{Status: OrderStatusPreparing, Label: "Preparing", StaffEditable: true},
+			{Status: OrderStatusReady, Label: "Ready for delivery"},
+			{Status: OrderStatusOutForDelivery, Label: "Out for delivery"},
+			{Status: OrderStatusFailedDelivery, Label: "Delivery failed"},
+			{Status: OrderStatusDelivered, Label: "Delivered", IsFinal: true},
+			{Status: OrderStatusCancelled, Label: "Cancelled", IsFinal: true},
+		},
+		Transitions: []WorkflowTransition{
+			{FromStatus: OrderStatusPending, ToStatus: OrderStatusPreparing},
+			{FromStatus: OrderStatusPending, ToStatus: OrderStatusCancelled, RestoresInventory: true, ClientAllowed: true},
+			{FromStatus: OrderStatusPreparing, ToStatus: OrderStatusReady, StampField: OrderTimestampReadyAt},
+			{FromStatus: OrderStatusPreparing, ToStatus: OrderStatusCancelled, RestoresInventory: true, ClientAllowed: true},
+			{FromStatus: OrderStatusReady, ToStatus: OrderStatusOutForDelivery, StampField: OrderTimestampOutForDeliveryAt},
+			{FromStatus: OrderStatusReady, ToStatus: OrderStatusCancelled, RestoresInventory: true},
+			{FromStatus: OrderStatusOutForDelivery, ToStatus: OrderStatusDelivered, StampField: OrderTimestampDeliveredAt},
+			{FromStatus: OrderStatusOutForDelivery, ToStatus: OrderStatusFailedDelivery},
+			{FromStatus: OrderStatusFailedDelivery, ToStatus: OrderStatusOutForDelivery, StampField: OrderTimestampOutForDeliveryAt},
+			{FromStatus: OrderStatusFailedDelivery, ToStatus: OrderStatusCancelled, RestoresInventory: true},
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultWorkflowsAreValid(t *testing.T) {
+	for _, w := range []*OrderWorkflow{DefaultOrderWorkflow(), DefaultDeliveryWorkflow()} {
+		if err := w.Validate(); err != nil {
+			t.Errorf("%s: Validate returned error: %v", w.Name, err)
+		}
+	}
+}
+
+func TestOrderWorkflowValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(w *OrderWorkflow)
+		want   string
+	}{
+		{
+			"no states",
+			func(w *OrderWorkflow) { w.States = nil },
+			"at least one state",
+		},
+		{
+			"uppercase status",
+			func(w *OrderWorkflow) { w.States[1].Status = "Preparing" },
+			"invalid status name",
+		},
+		{
+			"status too long",
+			func(w *OrderWorkflow) { w.States[1].Status = "waiting_for_volunteers" },
+			"invalid status name",
+		},
+		{
+			"duplicate state",
+			func(w *OrderWorkflow) { w.States = append(w.States, WorkflowState{Status: OrderStatusReady}) },
+			"duplicate state",
+		},
+		{
+			"final state staff editable",
+			func(w *OrderWorkflow) { w.States[3].StaffEditable = true },
+			"cannot be staff editable",
+		},
+		{
+			"unknown initial status",
+			func(w *OrderWorkflow) { w.InitialStatus = "submitted" },
+			"is not a workflow state",
+		},
+		{
+			"final initial status",
+			func(w *OrderWorkflow) { w.InitialStatus = OrderStatusCancelled },
+			"cannot be a final state",
+		},
+		{
+			"transition from unknown state",
+			func(w *OrderWorkflow) { w.Transitions[0].FromStatus = "submitted" },
+			"transition from unknown state",
+		},
+		{
+			"transition to unknown state",
+			func(w *OrderWorkflow) { w.Transitions[0].ToStatus = "submitted" },
+			"transition to unknown state",
+		},
+		{
+			"transition out of final state",
+			func(w *OrderWorkflow) {
+				w.Transitions = append(w.Transitions, WorkflowTransition{FromStatus: OrderStatusPickedUp, ToStatus: OrderStatusReady})
+			},
+			"cannot have outgoing transitions",
+		},
+		{
+			"unknown stamp field",
+			func(w *OrderWorkflow) { w.Transitions[0].StampField = "created_at" },
+			"unknown stamp field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := DefaultOrderWorkflow()
+			tt.modify(w)
+			err := w.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,15 @@ import (
 	"gorm.io/gorm"
 )
 
+// AssignmentStrategy controls how new orders are assigned to a pantry's staff
+type AssignmentStrategy string
+
+const (
+	AssignmentManual     AssignmentStrategy = "manual"      // Staff assign orders themselves
+	AssignmentRoundRobin AssignmentStrategy = "round_robin" // Least recently assigned staff member
+	AssignmentLeastOpen  AssignmentStrategy = "least_open"  // Staff member with the fewest open orders
+)
+
 // Pantry represents a community pantry
 type Pantry struct {
 	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -18,8 +27,20 @@ type Pantry struct {
 	ContactEmail string    `gorm:"not null" json:"contact_email"`
 	ContactPhone string    `json:"contact_phone"`
 	IsActive     bool      `gorm:"default:true" json:"is_active"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	Timezone     string    `gorm:"not null;default:'UTC'" json:"timezone"` // IANA name, used for pickup slot times
+	SlotRequired bool      `gorm:"default:false" json:"slot_required"`     // Clients must book a pickup slot at checkout
+	Delivers     bool      `gorm:"default:false" json:"delivers"`          // Pantry offers home delivery
+
+	// Hours a ready pickup order is held before it expires as a no-show; 0 holds it indefinitely
+	NoShowExpiryHours int `gorm:"not null;default:48" json:"no_show_expiry_hours"`
+
+	// Unassigned orders entering AutoAssignStatus (pending or preparing) are
+	// assigned to staff at the pantry using AssignmentStrategy
+	AssignmentStrategy AssignmentStrategy `gorm:"type:varchar(20);not null;default:'manual'" json:"assignment_strategy"`
+	AutoAssignStatus   OrderStatus        `gorm:"type:varchar(20);not null;default:'pending'" json:"auto_assign_status"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // BeforeCreate will set a UUID rather than numeric ID
@@ -29,3 +50,15 @@ func (p *Pantry) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// Location returns the pantry's time zone, falling back to UTC
+func (p *Pantry) Location() *time.Location {
+	if p.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
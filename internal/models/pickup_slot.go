@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PickupSlotTemplate defines recurring pickup windows for a pantry on one weekday
+type PickupSlotTemplate struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PantryID    uuid.UUID `gorm:"type:uuid;not null;index" json:"pantry_id"`
+	Pantry      Pantry    `gorm:"foreignKey:PantryID" json:"pantry,omitempty"`
+	DayOfWeek   int       `gorm:"not null" json:"day_of_week"`                // 0 = Sunday ... 6 = Saturday
+	StartTime   string    `gorm:"type:varchar(5);not null" json:"start_time"` // "HH:MM" in pantry local time
+	EndTime     string    `gorm:"type:varchar(5);not null" json:"end_time"`
+	SlotMinutes int       `gorm:"not null;default:15" json:"slot_minutes"`
+	Capacity    int       `gorm:"not null;default:8" json:"capacity"`
+	IsActive    bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (t *PickupSlotTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// PickupSlot is a concrete, bookable pickup window generated from a template
+type PickupSlot struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PantryID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_pickup_slot_window" json:"pantry_id"`
+	TemplateID  *uuid.UUID `gorm:"type:uuid" json:"template_id"`
+	StartsAt    time.Time  `gorm:"not null;uniqueIndex:idx_pickup_slot_window" json:"starts_at"`
+	EndsAt      time.Time  `gorm:"not null" json:"ends_at"`
+	Capacity    int        `gorm:"not null" json:"capacity"`
+	BookedCount int        `gorm:"not null;default:0" json:"booked_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (s *PickupSlot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Remaining returns the number of orders that can still book this slot
+func (s *PickupSlot) Remaining() int {
+	if s.BookedCount >= s.Capacity {
+		return 0
+	}
+	return s.Capacity - s.BookedCount
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StockHold reserves stock for an item sitting in a cart until it expires. The
+// held quantity is also tracked on Item.HeldQuantity so availability checks
+// stay a single conditional update.
+type StockHold struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CartID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_stock_holds_cart_item" json:"cart_id"`
+	ItemID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_stock_holds_cart_item" json:"item_id"`
+	Quantity  int       `gorm:"not null" json:"quantity"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (h *StockHold) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StockMovementType represents why an item's stock changed
+type StockMovementType string
+
+const (
+	StockReceive    StockMovementType = "receive"    // Donations and other intake
+	StockDistribute StockMovementType = "distribute" // Taken for an order
+	StockReturn     StockMovementType = "return"     // Given back by an order
+	StockAdjust     StockMovementType = "adjust"     // Count corrections
+	StockSpoilage   StockMovementType = "spoilage"   // Expired or damaged stock
+	StockTransfer   StockMovementType = "transfer"   // Moved to or from another item
+)
+
+// StockMovement is an append-only entry of an item's stock ledger. Item.Quantity
+// is the running balance of its movements.
+type StockMovement struct {
+	ID             uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ItemID         uuid.UUID         `gorm:"type:uuid;not null;index" json:"item_id"`
+	PantryID       uuid.UUID         `gorm:"type:uuid;not null;index" json:"pantry_id"`
+	Type           StockMovementType `gorm:"type:varchar(20);not null" json:"type"`
+	Quantity       int               `gorm:"not null" json:"quantity"` // Signed change
+	BalanceAfter   int               `gorm:"not null" json:"balance_after"`
+	ActorID        *uuid.UUID        `gorm:"type:uuid" json:"actor_id"` // nil for system actions
+	Actor          *User             `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Reason         string            `json:"reason"`
+	OrderID        *uuid.UUID        `gorm:"type:uuid;index" json:"order_id,omitempty"`
+	DonationID     *uuid.UUID        `gorm:"type:uuid;index" json:"donation_id,omitempty"`
+	TransferItemID *uuid.UUID        `gorm:"type:uuid" json:"transfer_item_id,omitempty"` // The other side of a transfer
+	LotID          *uuid.UUID        `gorm:"type:uuid;index" json:"lot_id,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (m *StockMovement) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsValid checks if a movement type is known
+func (t StockMovementType) IsValid() bool {
+	switch t {
+	case StockReceive, StockDistribute, StockReturn, StockAdjust, StockSpoilage, StockTransfer:
+		return true
+	}
+	return false
+}
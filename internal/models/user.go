@@ -26,6 +26,10 @@ type User struct {
 	Role         UserRole  `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
 	PantryID     *uuid.UUID `gorm:"type:uuid" json:"pantry_id"`
 	Pantry       *Pantry   `gorm:"foreignKey:PantryID" json:"pantry,omitempty"`
+
+	HouseholdSize int `gorm:"not null;default:1" json:"household_size"`
+	NoShowCount   int `gorm:"not null;default:0" json:"no_show_count"` // Ready orders never picked up
+
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
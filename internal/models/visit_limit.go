@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VisitLimitPolicy caps how many orders a household may place at a pantry
+// within a rolling window, e.g. 1 order per 7 days or 2 orders per 30 days
+type VisitLimitPolicy struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PantryID    uuid.UUID `gorm:"type:uuid;not null;index" json:"pantry_id"`
+	Pantry      Pantry    `gorm:"foreignKey:PantryID" json:"pantry,omitempty"`
+	MaxOrders   int       `gorm:"not null" json:"max_orders"`
+	WindowDays  int       `gorm:"not null" json:"window_days"`
+	Description string    `json:"description"`
+	IsActive    bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (p *VisitLimitPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// Window returns the length of the policy's rolling window
+func (p *VisitLimitPolicy) Window() time.Duration {
+	return time.Duration(p.WindowDays) * 24 * time.Hour
+}
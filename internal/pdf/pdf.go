@@ -0,0 +1,167 @@
+// Package pdf renders simple text documents, such as pick lists and packing
+// slips, as PDF files using the standard Helvetica fonts. It supports
+// left-aligned text lines in a few sizes with automatic page breaks.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// US Letter page geometry in points
+const (
+	pageWidth    = 612.0
+	pageHeight   = 792.0
+	marginLeft   = 50.0
+	marginTop    = 50.0
+	marginBottom = 50.0
+)
+
+// Document is a PDF document built line by line
+type Document struct {
+	pages   []*bytes.Buffer
+	current *bytes.Buffer
+	y       float64
+}
+
+// New creates an empty document with one blank page
+func New() *Document {
+	d := &Document{}
+	d.AddPage()
+	return d
+}
+
+// AddPage starts a new page
+func (d *Document) AddPage() {
+	d.current = &bytes.Buffer{}
+	d.pages = append(d.pages, d.current)
+	d.y = pageHeight - marginTop
+}
+
+// Title writes a large bold line
+func (d *Document) Title(text string) {
+	d.line(text, "F2", 16, 0)
+}
+
+// Heading writes a bold line
+func (d *Document) Heading(text string) {
+	d.line(text, "F2", 12, 0)
+}
+
+// Text writes a regular line
+func (d *Document) Text(text string) {
+	d.line(text, "F1", 10, 0)
+}
+
+// Indented writes a regular line indented by the given number of points
+func (d *Document) Indented(indent float64, text string) {
+	d.line(text, "F1", 10, indent)
+}
+
+// Columns writes a regular line with text placed at the given x offsets
+func (d *Document) Columns(offsets []float64, values ...string) {
+	d.advance(10)
+	for i, value := range values {
+		if i >= len(offsets) {
+			break
+		}
+		d.show("F1", 10, marginLeft+offsets[i], value)
+	}
+}
+
+// Space adds vertical space in points
+func (d *Document) Space(points float64) {
+	d.y -= points
+}
+
+// line writes one line of text, breaking to a new page when needed
+func (d *Document) line(text, font string, size, indent float64) {
+	d.advance(size)
+	d.show(font, size, marginLeft+indent, text)
+}
+
+// advance moves down one line of the given font size
+func (d *Document) advance(size float64) {
+	leading := size * 1.4
+	if d.y-leading < marginBottom {
+		d.AddPage()
+	}
+	d.y -= leading
+}
+
+// show draws text at x on the current line
+func (d *Document) show(font string, size, x float64, text string) {
+	fmt.Fprintf(d.current, "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, d.y, escape(text))
+}
+
+// WriteTo writes the document in PDF format
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page then takes
+	// two objects, the page itself and its content stream
+	buf.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// Bytes returns the document in PDF format
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// escape converts text to a PDF string literal body. Characters outside
+// Latin-1 cannot be shown by the standard fonts and are replaced.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			b.WriteByte('?')
+		case r >= 0xa0:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CartRepository handles database operations for carts
@@ -18,6 +19,11 @@ func NewCartRepository(db *gorm.DB) *CartRepository {
 	return &CartRepository{db: db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *CartRepository) WithTx(tx *gorm.DB) *CartRepository {
+	return &CartRepository{db: tx}
+}
+
 // Create creates a new cart
 func (r *CartRepository) Create(cart *models.Cart) error {
 	return r.db.Create(cart).Error
@@ -37,11 +43,22 @@ func (r *CartRepository) FindByID(id uuid.UUID) (*models.Cart, error) {
 	return &cart, nil
 }
 
-// FindActiveByUserID finds the active cart for a user
-func (r *CartRepository) FindActiveByUserID(userID uuid.UUID) (*models.Cart, error) {
-	var cart models.Cart
+// FindActiveByUserID finds the active carts of a user, one per pantry, most
+// recently changed first
+func (r *CartRepository) FindActiveByUserID(userID uuid.UUID) ([]models.Cart, error) {
+	var carts []models.Cart
 	err := r.db.Preload("Items.Item.Category").Preload("User").Preload("Pantry").
 		Where("user_id = ? AND status = ?", userID, models.CartStatusActive).
+		Order("updated_at DESC").
+		Find(&carts).Error
+	return carts, err
+}
+
+// FindActiveByUserAndPantry finds the active cart of a user at a pantry
+func (r *CartRepository) FindActiveByUserAndPantry(userID, pantryID uuid.UUID) (*models.Cart, error) {
+	var cart models.Cart
+	err := r.db.Preload("Items.Item.Category").Preload("User").Preload("Pantry").
+		Where("user_id = ? AND pantry_id = ? AND status = ?", userID, pantryID, models.CartStatusActive).
 		First(&cart).Error
 
 	if err != nil {
@@ -58,6 +75,42 @@ func (r *CartRepository) Update(cart *models.Cart) error {
 	return r.db.Save(cart).Error
 }
 
+// CancelActiveByPantry cancels every active cart at a pantry
+func (r *CartRepository) CancelActiveByPantry(pantryID uuid.UUID) error {
+	return r.db.Model(&models.Cart{}).
+		Where("pantry_id = ? AND status = ?", pantryID, models.CartStatusActive).
+		Update("status", models.CartStatusCancelled).Error
+}
+
+// MarkSubmitted flips an active cart to submitted. The conditional update locks the
+// cart row, so only one of several concurrent checkouts of the same cart succeeds.
+// Returns false if the cart was no longer active.
+func (r *CartRepository) MarkSubmitted(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.Cart{}).
+		Where("id = ? AND status = ?", id, models.CartStatusActive).
+		Update("status", models.CartStatusSubmitted)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// LockActive locks a cart row for the rest of the transaction. Returns false
+// if the cart is no longer active, so the caller must not change it.
+func (r *CartRepository) LockActive(id uuid.UUID) (bool, error) {
+	var cart models.Cart
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "status").
+		First(&cart, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return cart.Status == models.CartStatusActive, nil
+}
+
 // Delete deletes a cart
 func (r *CartRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&models.Cart{}, "id = ?", id).Error
@@ -68,9 +121,17 @@ func (r *CartRepository) AddItem(cartItem *models.CartItem) error {
 	return r.db.Create(cartItem).Error
 }
 
-// UpdateItem updates a cart item
+// UpdateItem updates a cart item. Preloaded associations are not written back,
+// so changing ItemID takes effect even when the old Item is loaded.
 func (r *CartRepository) UpdateItem(cartItem *models.CartItem) error {
-	return r.db.Save(cartItem).Error
+	return r.db.Omit(clause.Associations).Save(cartItem).Error
+}
+
+// SnapshotRequestedQuantities records the current line quantities of a cart as
+// the quantities the client requested
+func (r *CartRepository) SnapshotRequestedQuantities(cartID uuid.UUID) error {
+	return r.db.Model(&models.CartItem{}).Where("cart_id = ?", cartID).
+		UpdateColumn("requested_quantity", gorm.Expr("quantity")).Error
 }
 
 // RemoveItem removes an item from a cart
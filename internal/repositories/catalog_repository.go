@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CatalogRepository handles database operations for the product catalog
+type CatalogRepository struct {
+	db *gorm.DB
+}
+
+// NewCatalogRepository creates a new catalog repository
+func NewCatalogRepository(db *gorm.DB) *CatalogRepository {
+	return &CatalogRepository{db: db}
+}
+
+// FindByBarcode finds a catalog product by barcode
+func (r *CatalogRepository) FindByBarcode(code string) (*models.CatalogProduct, error) {
+	var product models.CatalogProduct
+	err := r.db.First(&product, "barcode = ?", code).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// Upsert inserts products, replacing the details of those already in the catalog
+func (r *CatalogRepository) Upsert(products []models.CatalogProduct) error {
+	if len(products) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "barcode"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "description", "brand", "unit", "category_name", "image_url", "updated_at"}),
+	}).CreateInBatches(&products, 500).Error
+}
+
+// Count counts the products in the catalog
+func (r *CatalogRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.CatalogProduct{}).Count(&count).Error
+	return count, err
+}
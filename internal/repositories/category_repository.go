@@ -18,6 +18,11 @@ func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
+// WithTx returns a repository that runs its queries in the given transaction
+func (r *CategoryRepository) WithTx(tx *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{db: tx}
+}
+
 // Create creates a new category
 func (r *CategoryRepository) Create(category *models.Category) error {
 	return r.db.Create(category).Error
@@ -7,6 +7,7 @@ import (
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // DonationRepository handles database operations for donations
@@ -19,34 +20,88 @@ func NewDonationRepository(db *gorm.DB) *DonationRepository {
 	return &DonationRepository{db: db}
 }
 
-// Create creates a new donation
+// WithTx returns a repository that runs its queries in the given transaction
+func (r *DonationRepository) WithTx(tx *gorm.DB) *DonationRepository {
+	return &DonationRepository{db: tx}
+}
+
+// Create creates a new donation along with its items
 func (r *DonationRepository) Create(donation *models.Donation) error {
 	return r.db.Create(donation).Error
 }
 
-// FindByID finds a donation by ID
+// FindByID finds a donation by ID with its items
 func (r *DonationRepository) FindByID(id uuid.UUID) (*models.Donation, error) {
 	var donation models.Donation
-	err := r.db.Preload("Pantry").First(&donation, "id = ?", id).Error
+	err := r.db.Preload("Pantry").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, name") }).
+		Preload("Items.Item").
+		First(&donation, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("donation not found")
+		}
+		return nil, err
+	}
+	return &donation, nil
+}
+
+// FindForUpdate finds a donation with its items and locks the donation row
+func (r *DonationRepository) FindForUpdate(id uuid.UUID) (*models.Donation, error) {
+	var donation models.Donation
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&donation, "id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("donation not found")
 		}
 		return nil, err
 	}
+	err = r.db.Where("donation_id = ?", id).Order("created_at, name").Find(&donation.Items).Error
+	if err != nil {
+		return nil, err
+	}
 	return &donation, nil
 }
 
-// Update updates a donation
+// Update updates a donation; its items are saved separately
 func (r *DonationRepository) Update(donation *models.Donation) error {
-	return r.db.Save(donation).Error
+	return r.db.Omit("Items").Save(donation).Error
 }
 
-// Delete deletes a donation
+// Delete deletes a donation and its items
 func (r *DonationRepository) Delete(id uuid.UUID) error {
+	if err := r.db.Delete(&models.DonationItem{}, "donation_id = ?", id).Error; err != nil {
+		return err
+	}
 	return r.db.Delete(&models.Donation{}, "id = ?", id).Error
 }
 
+// ReplaceItems replaces the items of a donation
+func (r *DonationRepository) ReplaceItems(donationID uuid.UUID, items []models.DonationItem) error {
+	if err := r.db.Delete(&models.DonationItem{}, "donation_id = ?", donationID).Error; err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	for i := range items {
+		items[i].DonationID = donationID
+	}
+	return r.db.Create(&items).Error
+}
+
+// UpdateItem saves a donation item
+func (r *DonationRepository) UpdateItem(item *models.DonationItem) error {
+	return r.db.Omit("Item").Save(item).Error
+}
+
+// MarkReceived records that a donation's items were added to stock
+func (r *DonationRepository) MarkReceived(id, receivedByID uuid.UUID, receivedAt time.Time) error {
+	return r.db.Model(&models.Donation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"received_at": receivedAt, "received_by_id": receivedByID}).Error
+}
+
 // FindAll finds all donations with optional filters
 func (r *DonationRepository) FindAll(pantryID *uuid.UUID, receiptSent *bool, startDate, endDate *time.Time, limit, offset int) ([]models.Donation, error) {
 	var donations []models.Donation
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ItemLotRepository handles database operations for item lots
+type ItemLotRepository struct {
+	db *gorm.DB
+}
+
+// NewItemLotRepository creates a new item lot repository
+func NewItemLotRepository(db *gorm.DB) *ItemLotRepository {
+	return &ItemLotRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *ItemLotRepository) WithTx(tx *gorm.DB) *ItemLotRepository {
+	return &ItemLotRepository{db: tx}
+}
+
+// Create creates a new lot
+func (r *ItemLotRepository) Create(lot *models.ItemLot) error {
+	return r.db.Create(lot).Error
+}
+
+// FindByID finds a lot by ID
+func (r *ItemLotRepository) FindByID(id uuid.UUID) (*models.ItemLot, error) {
+	var lot models.ItemLot
+	err := r.db.Preload("Item").First(&lot, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("lot not found")
+		}
+		return nil, err
+	}
+	return &lot, nil
+}
+
+// FindByItemID lists the lots of an item that still hold stock, first expiring first
+func (r *ItemLotRepository) FindByItemID(itemID uuid.UUID) ([]models.ItemLot, error) {
+	var lots []models.ItemLot
+	err := r.db.Where("item_id = ? AND quantity > 0", itemID).
+		Order("expires_at ASC NULLS LAST, received_at ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+// FindByIDsForUpdate finds and locks the given lots
+func (r *ItemLotRepository) FindByIDsForUpdate(ids []uuid.UUID) ([]models.ItemLot, error) {
+	var lots []models.ItemLot
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&lots).Error
+	return lots, err
+}
+
+// FindAllocatable finds and locks the lots of an item that still hold stock in
+// allocation order: first expiring first, lots without a date last. Expired
+// lots are skipped unless includeExpired is set, in which case they come first.
+func (r *ItemLotRepository) FindAllocatable(itemID uuid.UUID, includeExpired bool) ([]models.ItemLot, error) {
+	query := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("item_id = ? AND quantity > 0", itemID)
+	if includeExpired {
+		query = query.Order("expired DESC")
+	} else {
+		query = query.Where("expired = ? AND (expires_at IS NULL OR expires_at > ?)", false, time.Now())
+	}
+
+	var lots []models.ItemLot
+	err := query.Order("expires_at ASC NULLS LAST, received_at ASC").Find(&lots).Error
+	return lots, err
+}
+
+// FindLapsedForUpdate locks up to limit lots that are past their expiration date
+// but not yet marked expired, optionally of one item. Lots locked by another
+// transaction are skipped.
+func (r *ItemLotRepository) FindLapsedForUpdate(itemID *uuid.UUID, now time.Time, limit int) ([]models.ItemLot, error) {
+	query := r.db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("expired = ? AND expires_at <= ?", false, now)
+	if itemID != nil {
+		query = query.Where("item_id = ?", *itemID)
+	}
+
+	var lots []models.ItemLot
+	err := query.Order("item_id").Limit(limit).Find(&lots).Error
+	return lots, err
+}
+
+// FindExpiring lists the lots with stock that expire before the given time,
+// including already expired ones, first expiring first
+func (r *ItemLotRepository) FindExpiring(pantryID *uuid.UUID, before time.Time) ([]models.ItemLot, error) {
+	query := r.db.Preload("Item.Category").
+		Where("quantity > 0 AND expires_at IS NOT NULL AND expires_at <= ?", before)
+	if pantryID != nil {
+		query = query.Where("pantry_id = ?", *pantryID)
+	}
+
+	var lots []models.ItemLot
+	err := query.Order("expires_at ASC").Find(&lots).Error
+	return lots, err
+}
+
+// AdjustQuantity changes a lot's remaining quantity by delta
+func (r *ItemLotRepository) AdjustQuantity(id uuid.UUID, delta int) error {
+	return r.db.Model(&models.ItemLot{}).Where("id = ?", id).
+		UpdateColumn("quantity", gorm.Expr("quantity + ?", delta)).Error
+}
+
+// MarkExpired flags a lot as expired
+func (r *ItemLotRepository) MarkExpired(id uuid.UUID) error {
+	return r.db.Model(&models.ItemLot{}).Where("id = ?", id).Update("expired", true).Error
+}
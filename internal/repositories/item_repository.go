@@ -7,6 +7,7 @@ import (
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ItemRepository handles database operations for items
@@ -19,6 +20,11 @@ func NewItemRepository(db *gorm.DB) *ItemRepository {
 	return &ItemRepository{db: db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *ItemRepository) WithTx(tx *gorm.DB) *ItemRepository {
+	return &ItemRepository{db: tx}
+}
+
 // ItemFilter represents filtering options for items
 type ItemFilter struct {
 	PantryID   *uuid.UUID
@@ -26,6 +32,8 @@ type ItemFilter struct {
 	Search     string
 	Available  *bool
 	LowStock   bool
+
+	ActivePantry bool // Only items of active pantries
 }
 
 // Create creates a new item
@@ -46,9 +54,39 @@ func (r *ItemRepository) FindByID(id uuid.UUID) (*models.Item, error) {
 	return &item, nil
 }
 
-// Update updates an item
+// Update updates an item. Stock levels are changed through the stock ledger
+// and holds only, so they are never written back from a stale copy.
 func (r *ItemRepository) Update(item *models.Item) error {
-	return r.db.Save(item).Error
+	return r.db.Omit("quantity", "held_quantity", "expired_quantity").Save(item).Error
+}
+
+// FindByBarcode finds a pantry's item by barcode
+func (r *ItemRepository) FindByBarcode(pantryID uuid.UUID, code string) (*models.Item, error) {
+	var item models.Item
+	err := r.db.Preload("Category").Preload("Pantry").
+		First(&item, "pantry_id = ? AND barcode = ?", pantryID, code).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("item not found")
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// FindForUpdate finds and locks an item's stock columns
+func (r *ItemRepository) FindForUpdate(id uuid.UUID) (*models.Item, error) {
+	var item models.Item
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "name", "pantry_id", "quantity", "held_quantity", "expired_quantity").
+		First(&item, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("item not found")
+		}
+		return nil, err
+	}
+	return &item, nil
 }
 
 // Delete deletes an item
@@ -80,6 +118,18 @@ func (r *ItemRepository) Count(filter ItemFilter) (int64, error) {
 	return count, err
 }
 
+// FindByPantryID returns a page of a pantry's items with their categories,
+// ordered by category and name
+func (r *ItemRepository) FindByPantryID(pantryID uuid.UUID, limit, offset int) ([]models.Item, error) {
+	var items []models.Item
+	err := r.db.Joins("Category").
+		Where("items.pantry_id = ?", pantryID).
+		Order(`"Category".name, items.name, items.id`).
+		Limit(limit).Offset(offset).
+		Find(&items).Error
+	return items, err
+}
+
 // FindLowStock finds items that are low on stock
 func (r *ItemRepository) FindLowStock(pantryID *uuid.UUID) ([]models.Item, error) {
 	var items []models.Item
@@ -94,15 +144,67 @@ func (r *ItemRepository) FindLowStock(pantryID *uuid.UUID) ([]models.Item, error
 	return items, err
 }
 
-// UpdateQuantity updates the quantity of an item
-func (r *ItemRepository) UpdateQuantity(id uuid.UUID, quantity int) error {
-	return r.db.Model(&models.Item{}).Where("id = ?", id).Update("quantity", quantity).Error
+// AdjustQuantity adjusts the quantity of an item by a delta (can be negative)
+// unless that would take it below zero. Returns false if it would.
+func (r *ItemRepository) AdjustQuantity(id uuid.UUID, delta int) (bool, error) {
+	result := r.db.Model(&models.Item{}).Where("id = ? AND quantity + ? >= 0", id, delta).
+		UpdateColumn("quantity", gorm.Expr("quantity + ?", delta))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
 }
 
-// AdjustQuantity adjusts the quantity of an item by a delta (can be negative)
-func (r *ItemRepository) AdjustQuantity(id uuid.UUID, delta int) error {
+// DecrementStock reduces an item's quantity by the given amount only if the item
+// is available and has enough stock that is neither held by carts nor expired.
+// The check and the update happen in a single statement, so concurrent callers
+// cannot both take the last unit. Items that reach zero are marked unavailable.
+// Returns false if the stock was insufficient.
+func (r *ItemRepository) DecrementStock(id uuid.UUID, quantity int) (bool, error) {
+	result := r.db.Model(&models.Item{}).
+		Where("id = ? AND is_available = ? AND quantity - held_quantity - expired_quantity >= ?", id, true, quantity).
+		Updates(map[string]interface{}{
+			"quantity":     gorm.Expr("quantity - ?", quantity),
+			"is_available": gorm.Expr("quantity - ? > 0", quantity),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RestoreStock returns quantity to an item, making it available again if a
+// checkout had sold it out
+func (r *ItemRepository) RestoreStock(id uuid.UUID, quantity int) error {
 	return r.db.Model(&models.Item{}).Where("id = ?", id).
-		UpdateColumn("quantity", gorm.Expr("quantity + ?", delta)).Error
+		Updates(map[string]interface{}{
+			"quantity":     gorm.Expr("quantity + ?", quantity),
+			"is_available": gorm.Expr("CASE WHEN quantity = 0 THEN ? ELSE is_available END", true),
+		}).Error
+}
+
+// AdjustExpired changes the quantity of an item held in expired lots by delta
+func (r *ItemRepository) AdjustExpired(id uuid.UUID, delta int) error {
+	return r.db.Model(&models.Item{}).Where("id = ?", id).
+		UpdateColumn("expired_quantity", gorm.Expr("GREATEST(expired_quantity + ?, 0)", delta)).Error
+}
+
+// HoldStock reserves quantity of an item for a cart if the item is available
+// and enough of its unexpired stock is not held already. Returns false if it is not.
+func (r *ItemRepository) HoldStock(id uuid.UUID, quantity int) (bool, error) {
+	result := r.db.Model(&models.Item{}).
+		Where("id = ? AND is_available = ? AND quantity - held_quantity - expired_quantity >= ?", id, true, quantity).
+		UpdateColumn("held_quantity", gorm.Expr("held_quantity + ?", quantity))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseHold returns held quantity of an item to general availability
+func (r *ItemRepository) ReleaseHold(id uuid.UUID, quantity int) error {
+	return r.db.Model(&models.Item{}).Where("id = ?", id).
+		UpdateColumn("held_quantity", gorm.Expr("GREATEST(held_quantity - ?, 0)", quantity)).Error
 }
 
 // applyFilters applies filtering conditions to a query
@@ -128,5 +230,9 @@ func (r *ItemRepository) applyFilters(query *gorm.DB, filter ItemFilter) *gorm.D
 		query = query.Where("quantity <= low_stock_threshold")
 	}
 
+	if filter.ActivePantry {
+		query = query.Where("pantry_id IN (?)", r.db.Model(&models.Pantry{}).Select("id").Where("is_active = ?", true))
+	}
+
 	return query
 }
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderEventRepository handles database operations for order history
+type OrderEventRepository struct {
+	db *gorm.DB
+}
+
+// NewOrderEventRepository creates a new order event repository
+func NewOrderEventRepository(db *gorm.DB) *OrderEventRepository {
+	return &OrderEventRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *OrderEventRepository) WithTx(tx *gorm.DB) *OrderEventRepository {
+	return &OrderEventRepository{db: tx}
+}
+
+// Create appends an event to an order's history
+func (r *OrderEventRepository) Create(event *models.OrderEvent) error {
+	return r.db.Create(event).Error
+}
+
+// FindByOrderID returns the history of an order, oldest first
+func (r *OrderEventRepository) FindByOrderID(orderID uuid.UUID) ([]models.OrderEvent, error) {
+	var events []models.OrderEvent
+	err := r.db.Preload("Actor").
+		Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Find(&events).Error
+	return events, err
+}
+
+// AssignmentTime is when a staff member was last assigned an order
+type AssignmentTime struct {
+	AssignedToID   uuid.UUID
+	LastAssignedAt time.Time
+}
+
+// LastAssignmentTimes returns, per staff member, when they were last assigned
+// one of a pantry's orders
+func (r *OrderEventRepository) LastAssignmentTimes(pantryID uuid.UUID) ([]AssignmentTime, error) {
+	var times []AssignmentTime
+	err := r.db.Table("order_events AS e").
+		Select("e.assigned_to_id, MAX(e.created_at) AS last_assigned_at").
+		Joins("JOIN orders o ON o.id = e.order_id").
+		Where("o.pantry_id = ? AND e.type = ? AND e.assigned_to_id IS NOT NULL", pantryID, models.OrderEventAssignment).
+		Group("e.assigned_to_id").
+		Scan(&times).Error
+	return times, err
+}
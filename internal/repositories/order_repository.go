@@ -2,22 +2,55 @@ package repositories
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// activePickupCodeIndex keeps active pickup codes unique within a pantry
+const activePickupCodeIndex = "idx_orders_active_pickup_code"
+
 // OrderRepository handles database operations for orders
 type OrderRepository struct {
 	db *gorm.DB
 }
 
+// OrderFilter represents filtering and sorting options for orders
+type OrderFilter struct {
+	Status        *models.OrderStatus
+	PantryID      *uuid.UUID
+	SubmittedFrom *time.Time // Inclusive
+	SubmittedTo   *time.Time // Exclusive
+	AssignedToID  *uuid.UUID
+	Unassigned    bool
+	Search        string // Client name or email, or walk-in name
+	PickupSlotID  *uuid.UUID
+	Sort          string // One of OrderSortFields, prefixed with "-" for descending
+}
+
+// OrderSortFields are the columns orders can be sorted by
+var OrderSortFields = map[string]string{
+	"created_at":   "orders.created_at",
+	"submitted_at": "orders.submitted_at",
+	"pickup_start": "orders.pickup_start",
+	"status":       "orders.status",
+}
+
 // NewOrderRepository creates a new order repository
 func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *OrderRepository) WithTx(tx *gorm.DB) *OrderRepository {
+	return &OrderRepository{db: tx}
+}
+
 // Create creates a new order
 func (r *OrderRepository) Create(order *models.Order) error {
 	return r.db.Create(order).Error
@@ -26,8 +59,22 @@ func (r *OrderRepository) Create(order *models.Order) error {
 // FindByID finds an order by ID
 func (r *OrderRepository) FindByID(id uuid.UUID) (*models.Order, error) {
 	var order models.Order
-	err := r.db.Preload("Cart.Items.Item").Preload("User").Preload("Pantry").
-		Preload("AssignedTo").First(&order, "id = ?", id).Error
+	err := r.db.Preload("Cart.Items.Item.Category").Preload("Cart.Items.SubstitutedFor").Preload("User").Preload("Pantry").
+		Preload("AssignedTo").Preload("Driver").Preload("CreatedBy").First(&order, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// FindByIDForUpdate finds an order by ID and locks its row until the
+// surrounding transaction ends. Associations are not loaded.
+func (r *OrderRepository) FindByIDForUpdate(id uuid.UUID) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("order not found")
@@ -37,9 +84,22 @@ func (r *OrderRepository) FindByID(id uuid.UUID) (*models.Order, error) {
 	return &order, nil
 }
 
-// Update updates an order
+// Update updates an order. Preloaded associations are not written back.
 func (r *OrderRepository) Update(order *models.Order) error {
-	return r.db.Save(order).Error
+	return r.db.Omit(clause.Associations).Save(order).Error
+}
+
+// FindOpenStatuses returns the distinct statuses of a pantry's orders of one
+// fulfillment type that are not in one of the given closed statuses
+func (r *OrderRepository) FindOpenStatuses(pantryID uuid.UUID, fulfillment models.FulfillmentType, closed []models.OrderStatus) ([]models.OrderStatus, error) {
+	var statuses []models.OrderStatus
+	query := r.db.Model(&models.Order{}).Distinct("status").
+		Where("pantry_id = ? AND fulfillment_type = ?", pantryID, fulfillment)
+	if len(closed) > 0 {
+		query = query.Where("status NOT IN ?", closed)
+	}
+	err := query.Pluck("status", &statuses).Error
+	return statuses, err
 }
 
 // FindByUserID finds all orders for a user
@@ -78,34 +138,303 @@ func (r *OrderRepository) CountByPantryID(pantryID uuid.UUID) (int64, error) {
 	return count, err
 }
 
-// FindAll finds all orders with optional filtering
-func (r *OrderRepository) FindAll(status *models.OrderStatus, limit, offset int) ([]models.Order, error) {
+// FindAll finds all orders matching the filter, sorted as requested
+func (r *OrderRepository) FindAll(filter OrderFilter, limit, offset int) ([]models.Order, error) {
 	var orders []models.Order
 	query := r.db.Preload("Cart.Items.Item").Preload("User").Preload("Pantry").Preload("AssignedTo")
 
-	if status != nil {
-		query = query.Where("status = ?", *status)
-	}
+	// Apply filters
+	query = r.applyFilters(query, filter)
 
-	err := query.Order("created_at DESC").
+	err := query.Order(orderSort(filter.Sort)).
 		Limit(limit).Offset(offset).
 		Find(&orders).Error
 	return orders, err
 }
 
-// CountAll counts all orders with optional status filter
-func (r *OrderRepository) CountAll(status *models.OrderStatus) (int64, error) {
+// CountAll counts all orders matching the filter
+func (r *OrderRepository) CountAll(filter OrderFilter) (int64, error) {
 	var count int64
 	query := r.db.Model(&models.Order{})
 
-	if status != nil {
-		query = query.Where("status = ?", *status)
-	}
+	// Apply filters
+	query = r.applyFilters(query, filter)
 
 	err := query.Count(&count).Error
 	return count, err
 }
 
+// applyFilters applies order filtering conditions to a query
+func (r *OrderRepository) applyFilters(query *gorm.DB, filter OrderFilter) *gorm.DB {
+	if filter.Status != nil {
+		query = query.Where("orders.status = ?", *filter.Status)
+	}
+
+	if filter.PantryID != nil {
+		query = query.Where("orders.pantry_id = ?", *filter.PantryID)
+	}
+
+	if filter.SubmittedFrom != nil {
+		query = query.Where("orders.submitted_at >= ?", *filter.SubmittedFrom)
+	}
+
+	if filter.SubmittedTo != nil {
+		query = query.Where("orders.submitted_at < ?", *filter.SubmittedTo)
+	}
+
+	if filter.AssignedToID != nil {
+		query = query.Where("orders.assigned_to_id = ?", *filter.AssignedToID)
+	} else if filter.Unassigned {
+		query = query.Where("orders.assigned_to_id IS NULL")
+	}
+
+	if filter.Search != "" {
+		searchTerm := "%" + strings.ToLower(filter.Search) + "%"
+		query = query.Where(`LOWER(orders.walk_in_name) LIKE ? OR orders.user_id IN (
+			SELECT id FROM users
+			WHERE LOWER(first_name || ' ' || last_name) LIKE ? OR LOWER(email) LIKE ?)`,
+			searchTerm, searchTerm, searchTerm)
+	}
+
+	if filter.PickupSlotID != nil {
+		query = query.Where("orders.pickup_slot_id = ?", *filter.PickupSlotID)
+	}
+
+	return query
+}
+
+// orderSort returns the ORDER BY clause for a sort option, newest first by default
+func orderSort(sort string) string {
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = sort[1:]
+	}
+	column, ok := OrderSortFields[sort]
+	if !ok {
+		return "orders.created_at DESC"
+	}
+	// Ties are broken by age so pages stay stable
+	return column + " " + direction + " NULLS LAST, orders.created_at DESC"
+}
+
+// AssigneeStatusCount is the number of a pantry's orders in one status
+// assigned to one staff member; AssignedToID is nil for unassigned orders
+type AssigneeStatusCount struct {
+	AssignedToID *uuid.UUID
+	Status       models.OrderStatus
+	Count        int
+}
+
+// CountOpenByAssignee counts a pantry's orders outside the closed statuses by
+// assignee and status
+func (r *OrderRepository) CountOpenByAssignee(pantryID uuid.UUID, closed []models.OrderStatus) ([]AssigneeStatusCount, error) {
+	query := r.db.Model(&models.Order{}).
+		Select("assigned_to_id, status, COUNT(*) AS count").
+		Where("pantry_id = ?", pantryID)
+	if len(closed) > 0 {
+		query = query.Where("status NOT IN ?", closed)
+	}
+
+	var counts []AssigneeStatusCount
+	err := query.Group("assigned_to_id, status").Scan(&counts).Error
+	return counts, err
+}
+
+// FindDeliveries finds a pantry's delivery orders in a status, optionally limited
+// to delivery windows starting within [from, to). Results are sorted by zip code.
+func (r *OrderRepository) FindDeliveries(pantryID uuid.UUID, status models.OrderStatus, from, to *time.Time) ([]models.Order, error) {
+	var orders []models.Order
+	query := r.db.Preload("Cart.Items.Item").Preload("User").Preload("Pantry").Preload("Driver").
+		Where("pantry_id = ? AND fulfillment_type = ? AND status = ?", pantryID, models.FulfillmentDelivery, status)
+
+	if from != nil {
+		query = query.Where("delivery_window_start >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("delivery_window_start < ?", *to)
+	}
+
+	err := query.Order("delivery_zip_code ASC, delivery_window_start ASC").Find(&orders).Error
+	return orders, err
+}
+
+// FindNoShowsForUpdate finds up to limit ready pickup orders held longer than
+// their pantry's no-show threshold whose workflow allows them to expire, and
+// locks them. Rows already locked by another transaction are skipped, so
+// several workers can run concurrently.
+func (r *OrderRepository) FindNoShowsForUpdate(now time.Time, limit int) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "orders"}, Options: "SKIP LOCKED"}).
+		Select("orders.*").
+		Joins("JOIN pantries ON pantries.id = orders.pantry_id").
+		Where("orders.status = ? AND orders.fulfillment_type = ?", models.OrderStatusReady, models.FulfillmentPickup).
+		Where("pantries.no_show_expiry_hours > 0 AND orders.ready_at < ?::timestamptz - pantries.no_show_expiry_hours * INTERVAL '1 hour'", now).
+		Where(`EXISTS (
+			SELECT 1 FROM workflow_transitions wt
+			JOIN order_workflows w ON w.id = wt.workflow_id
+			WHERE wt.from_status = ? AND wt.to_status = ? AND w.fulfillment_type = orders.fulfillment_type
+			AND (w.pantry_id = orders.pantry_id OR (w.pantry_id IS NULL AND NOT EXISTS (
+				SELECT 1 FROM order_workflows pw
+				WHERE pw.pantry_id = orders.pantry_id AND pw.fulfillment_type = orders.fulfillment_type))))`,
+			models.OrderStatusReady, models.OrderStatusExpired).
+		Order("orders.ready_at ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+// FindByPickupCode finds a pantry's order by its active pickup code
+func (r *OrderRepository) FindByPickupCode(pantryID uuid.UUID, code string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Where("pantry_id = ? AND pickup_code = ? AND pickup_code_invalidated_at IS NULL", pantryID, code).
+		First(&order).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// IsPickupCodeConflict reports whether err comes from saving an order whose
+// pickup code is already active at its pantry
+func IsPickupCodeConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == activePickupCodeIndex
+}
+
+// FindForPickList finds a pantry's orders in a status for preparation, with
+// items and their categories loaded. When from and to are set, only orders
+// scheduled in [from, to) are included; an order is scheduled at its pickup
+// slot, its delivery window, or otherwise its submission time.
+func (r *OrderRepository) FindForPickList(pantryID uuid.UUID, status models.OrderStatus, from, to *time.Time) ([]models.Order, error) {
+	var orders []models.Order
+	query := r.db.Preload("Cart.Items.Item.Category").Preload("User").Preload("Pantry").
+		Where("pantry_id = ? AND status = ?", pantryID, status)
+
+	if from != nil && to != nil {
+		query = query.Where("COALESCE(pickup_start, delivery_window_start, submitted_at) >= ? AND COALESCE(pickup_start, delivery_window_start, submitted_at) < ?", *from, *to)
+	}
+
+	err := query.Order("submitted_at ASC").Find(&orders).Error
+	return orders, err
+}
+
+// ItemDistribution is the quantity of one item requested by clients and
+// actually handed out
+type ItemDistribution struct {
+	ItemID      uuid.UUID `json:"item_id"`
+	ItemName    string    `json:"item_name"`
+	Category    string    `json:"category"`
+	Unit        string    `json:"unit"`
+	Requested   int       `json:"requested"`
+	Distributed int       `json:"distributed"`
+}
+
+// completedOrder matches orders in a final state of the workflow in effect for
+// their pantry that is not reached by abandoning the order, so the food was
+// handed out. Pantries without their own workflow use the default one.
+const completedOrder = `o.status <> ? AND EXISTS (
+	SELECT 1 FROM order_workflows w
+	JOIN workflow_states ws ON ws.workflow_id = w.id AND ws.status = o.status AND ws.is_final
+	WHERE w.fulfillment_type = o.fulfillment_type
+		AND (w.pantry_id = o.pantry_id OR (w.pantry_id IS NULL AND NOT EXISTS (
+			SELECT 1 FROM order_workflows pw WHERE pw.pantry_id = o.pantry_id AND pw.fulfillment_type = o.fulfillment_type)))
+		AND NOT EXISTS (
+			SELECT 1 FROM workflow_transitions wt
+			WHERE wt.workflow_id = w.id AND wt.to_status = o.status AND wt.restores_inventory))`
+
+// completedAt is when an order was completed: its pickup or delivery stamp, or
+// for workflows that stamp neither its last update, which is when it was closed
+const completedAt = "COALESCE(o.picked_up_at, o.delivered_at, o.updated_at)"
+
+// distributionFilter builds the WHERE clause shared by the distribution queries
+func distributionFilter(pantryID *uuid.UUID, from, to *time.Time) (string, []interface{}) {
+	where := completedOrder
+	args := []interface{}{models.OrderStatusCancelled}
+	if pantryID != nil {
+		where += " AND o.pantry_id = ?"
+		args = append(args, *pantryID)
+	}
+	if from != nil {
+		where += " AND " + completedAt + " >= ?"
+		args = append(args, *from)
+	}
+	if to != nil {
+		where += " AND " + completedAt + " < ?"
+		args = append(args, *to)
+	}
+	return where, args
+}
+
+// DistributionByItem sums requested and distributed quantities per item over
+// orders completed within [from, to). Requested
+// quantities of substituted lines count toward the item originally asked for.
+func (r *OrderRepository) DistributionByItem(pantryID *uuid.UUID, from, to *time.Time) ([]ItemDistribution, error) {
+	where, args := distributionFilter(pantryID, from, to)
+	query := `SELECT i.id AS item_id, i.name AS item_name, c.name AS category, i.unit AS unit,
+			SUM(l.requested) AS requested, SUM(l.distributed) AS distributed
+		FROM (
+			SELECT COALESCE(ci.substituted_for_id, ci.item_id) AS item_id, ci.requested_quantity AS requested, 0 AS distributed
+			FROM cart_items ci JOIN orders o ON o.cart_id = ci.cart_id
+			WHERE ` + where + `
+			UNION ALL
+			SELECT ci.item_id, 0, ci.quantity
+			FROM cart_items ci JOIN orders o ON o.cart_id = ci.cart_id
+			WHERE ` + where + `
+		) l
+		JOIN items i ON i.id = l.item_id
+		LEFT JOIN categories c ON c.id = i.category_id
+		GROUP BY i.id, i.name, c.name, i.unit
+		ORDER BY c.name, i.name`
+
+	var rows []ItemDistribution
+	err := r.db.Raw(query, append(args, args...)...).Scan(&rows).Error
+	return rows, err
+}
+
+// ServiceCounts summarises who was served by a set of orders
+type ServiceCounts struct {
+	Orders         int64 `json:"orders"`
+	WalkInOrders   int64 `json:"walk_in_orders"`
+	AssistedOrders int64 `json:"assisted_orders"`
+	// Distinct clients plus one per walk-in order, since walk-ins cannot be told apart
+	HouseholdsServed int64 `json:"households_served"`
+}
+
+// CountServed counts orders completed within [from, to),
+// including walk-in and staff-assisted orders
+func (r *OrderRepository) CountServed(pantryID *uuid.UUID, from, to *time.Time) (*ServiceCounts, error) {
+	where, args := distributionFilter(pantryID, from, to)
+	var counts ServiceCounts
+	err := r.db.Table("orders AS o").
+		Select(`COUNT(*) AS orders,
+			COUNT(*) FILTER (WHERE o.user_id IS NULL) AS walk_in_orders,
+			COUNT(*) FILTER (WHERE o.created_by_id IS NOT NULL) AS assisted_orders,
+			COUNT(DISTINCT o.user_id) + COUNT(*) FILTER (WHERE o.user_id IS NULL) AS households_served`).
+		Where(where, args...).Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
+
+// FindSubmittedTimesSince returns the submission times of a user's orders at a
+// pantry submitted after since, newest first. Orders in the excluded statuses
+// are skipped.
+func (r *OrderRepository) FindSubmittedTimesSince(userID, pantryID uuid.UUID, since time.Time, excluded []models.OrderStatus) ([]time.Time, error) {
+	var times []time.Time
+	query := r.db.Model(&models.Order{}).
+		Where("user_id = ? AND pantry_id = ? AND submitted_at > ?", userID, pantryID, since)
+	if len(excluded) > 0 {
+		query = query.Where("status NOT IN ?", excluded)
+	}
+	err := query.Order("submitted_at DESC").Pluck("submitted_at", &times).Error
+	return times, err
+}
+
 // UpdateStatus updates the status of an order
 func (r *OrderRepository) UpdateStatus(id uuid.UUID, status models.OrderStatus) error {
 	return r.db.Model(&models.Order{}).Where("id = ?", id).
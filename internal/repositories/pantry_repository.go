@@ -6,6 +6,7 @@ import (
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PantryRepository handles database operations for pantries
@@ -18,6 +19,11 @@ func NewPantryRepository(db *gorm.DB) *PantryRepository {
 	return &PantryRepository{db: db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *PantryRepository) WithTx(tx *gorm.DB) *PantryRepository {
+	return &PantryRepository{db: tx}
+}
+
 // Create creates a new pantry
 func (r *PantryRepository) Create(pantry *models.Pantry) error {
 	return r.db.Create(pantry).Error
@@ -36,6 +42,17 @@ func (r *PantryRepository) FindByID(id uuid.UUID) (*models.Pantry, error) {
 	return &pantry, nil
 }
 
+// LockByID locks a pantry's row until the surrounding transaction ends. It is
+// used to serialize automatic staff assignment within a pantry.
+func (r *PantryRepository) LockByID(id uuid.UUID) error {
+	var pantry models.Pantry
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&pantry, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("pantry not found")
+	}
+	return err
+}
+
 // Update updates a pantry
 func (r *PantryRepository) Update(pantry *models.Pantry) error {
 	return r.db.Save(pantry).Error
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PickupSlotRepository handles database operations for pickup slots and their templates
+type PickupSlotRepository struct {
+	db *gorm.DB
+}
+
+// NewPickupSlotRepository creates a new pickup slot repository
+func NewPickupSlotRepository(db *gorm.DB) *PickupSlotRepository {
+	return &PickupSlotRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *PickupSlotRepository) WithTx(tx *gorm.DB) *PickupSlotRepository {
+	return &PickupSlotRepository{db: tx}
+}
+
+// CreateTemplate creates a new slot template
+func (r *PickupSlotRepository) CreateTemplate(template *models.PickupSlotTemplate) error {
+	return r.db.Create(template).Error
+}
+
+// FindTemplateByID finds a slot template by ID
+func (r *PickupSlotRepository) FindTemplateByID(id uuid.UUID) (*models.PickupSlotTemplate, error) {
+	var template models.PickupSlotTemplate
+	err := r.db.First(&template, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("slot template not found")
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// FindTemplatesByPantryID finds all slot templates for a pantry
+func (r *PickupSlotRepository) FindTemplatesByPantryID(pantryID uuid.UUID) ([]models.PickupSlotTemplate, error) {
+	var templates []models.PickupSlotTemplate
+	err := r.db.Where("pantry_id = ?", pantryID).
+		Order("day_of_week ASC, start_time ASC").
+		Find(&templates).Error
+	return templates, err
+}
+
+// FindActiveTemplates finds the active slot templates of a pantry for a weekday
+func (r *PickupSlotRepository) FindActiveTemplates(pantryID uuid.UUID, dayOfWeek time.Weekday) ([]models.PickupSlotTemplate, error) {
+	var templates []models.PickupSlotTemplate
+	err := r.db.Where("pantry_id = ? AND day_of_week = ? AND is_active = ?", pantryID, int(dayOfWeek), true).
+		Order("start_time ASC").
+		Find(&templates).Error
+	return templates, err
+}
+
+// UpdateTemplate updates a slot template
+func (r *PickupSlotRepository) UpdateTemplate(template *models.PickupSlotTemplate) error {
+	return r.db.Save(template).Error
+}
+
+// DeleteTemplate deletes a slot template
+func (r *PickupSlotRepository) DeleteTemplate(id uuid.UUID) error {
+	return r.db.Delete(&models.PickupSlotTemplate{}, "id = ?", id).Error
+}
+
+// RetireTemplateSlots closes the slots generated from a template that start
+// after the given time: unbooked slots are deleted and booked ones keep their
+// bookings but take no new ones
+func (r *PickupSlotRepository) RetireTemplateSlots(templateID uuid.UUID, after time.Time) error {
+	err := r.db.Where("template_id = ? AND starts_at > ? AND booked_count = 0", templateID, after).
+		Delete(&models.PickupSlot{}).Error
+	if err != nil {
+		return err
+	}
+	return r.db.Model(&models.PickupSlot{}).
+		Where("template_id = ? AND starts_at > ?", templateID, after).
+		UpdateColumn("capacity", gorm.Expr("booked_count")).Error
+}
+
+// EnsureSlots inserts the given slots, skipping any window that already exists
+func (r *PickupSlotRepository) EnsureSlots(slots []models.PickupSlot) error {
+	if len(slots) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&slots).Error
+}
+
+// FindSlotByID finds a pickup slot by ID
+func (r *PickupSlotRepository) FindSlotByID(id uuid.UUID) (*models.PickupSlot, error) {
+	var slot models.PickupSlot
+	err := r.db.First(&slot, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("pickup slot not found")
+		}
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// FindSlotsInRange finds the slots of a pantry starting within [start, end)
+func (r *PickupSlotRepository) FindSlotsInRange(pantryID uuid.UUID, start, end time.Time) ([]models.PickupSlot, error) {
+	var slots []models.PickupSlot
+	err := r.db.Where("pantry_id = ? AND starts_at >= ? AND starts_at < ?", pantryID, start, end).
+		Order("starts_at ASC").
+		Find(&slots).Error
+	return slots, err
+}
+
+// Reserve books one place in a slot if it has capacity left and has not started yet.
+// Returns false if the slot is full or in the past.
+func (r *PickupSlotRepository) Reserve(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.PickupSlot{}).
+		Where("id = ? AND booked_count < capacity AND starts_at > ?", id, time.Now()).
+		UpdateColumn("booked_count", gorm.Expr("booked_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Release frees one place in a slot
+func (r *PickupSlotRepository) Release(id uuid.UUID) error {
+	return r.db.Model(&models.PickupSlot{}).
+		Where("id = ? AND booked_count > 0", id).
+		UpdateColumn("booked_count", gorm.Expr("booked_count - 1")).Error
+}
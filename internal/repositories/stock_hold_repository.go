@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockHoldRepository handles database operations for cart stock holds
+type StockHoldRepository struct {
+	db *gorm.DB
+}
+
+// NewStockHoldRepository creates a new stock hold repository
+func NewStockHoldRepository(db *gorm.DB) *StockHoldRepository {
+	return &StockHoldRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *StockHoldRepository) WithTx(tx *gorm.DB) *StockHoldRepository {
+	return &StockHoldRepository{db: tx}
+}
+
+// Find finds the hold of a cart on an item, returning nil if there is none
+func (r *StockHoldRepository) Find(cartID, itemID uuid.UUID) (*models.StockHold, error) {
+	var hold models.StockHold
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("cart_id = ? AND item_id = ?", cartID, itemID).
+		First(&hold).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// FindByCartID finds and locks all holds of a cart
+func (r *StockHoldRepository) FindByCartID(cartID uuid.UUID) ([]models.StockHold, error) {
+	var holds []models.StockHold
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("cart_id = ?", cartID).
+		Order("item_id").
+		Find(&holds).Error
+	return holds, err
+}
+
+// ListByCartID lists the holds of a cart without locking them
+func (r *StockHoldRepository) ListByCartID(cartID uuid.UUID) ([]models.StockHold, error) {
+	var holds []models.StockHold
+	err := r.db.Where("cart_id = ?", cartID).Find(&holds).Error
+	return holds, err
+}
+
+// FindByPantryID finds and locks the holds of all active carts at a pantry
+func (r *StockHoldRepository) FindByPantryID(pantryID uuid.UUID) ([]models.StockHold, error) {
+	var holds []models.StockHold
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("cart_id IN (?)", r.db.Model(&models.Cart{}).Select("id").
+			Where("pantry_id = ? AND status = ?", pantryID, models.CartStatusActive)).
+		Order("item_id").
+		Find(&holds).Error
+	return holds, err
+}
+
+// FindExpiredForUpdate locks up to limit holds that expired before now. Holds
+// locked by another transaction are skipped so several sweepers can run at once.
+func (r *StockHoldRepository) FindExpiredForUpdate(now time.Time, limit int) ([]models.StockHold, error) {
+	var holds []models.StockHold
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("expires_at < ?", now).
+		Order("item_id").
+		Limit(limit).
+		Find(&holds).Error
+	return holds, err
+}
+
+// Save creates or updates a hold
+func (r *StockHoldRepository) Save(hold *models.StockHold) error {
+	return r.db.Save(hold).Error
+}
+
+// ExtendCart moves the expiry of all holds of a cart to expiresAt
+func (r *StockHoldRepository) ExtendCart(cartID uuid.UUID, expiresAt time.Time) error {
+	return r.db.Model(&models.StockHold{}).Where("cart_id = ?", cartID).
+		Update("expires_at", expiresAt).Error
+}
+
+// Delete deletes a hold
+func (r *StockHoldRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.StockHold{}, "id = ?", id).Error
+}
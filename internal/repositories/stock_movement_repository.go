@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StockMovementRepository handles database operations for the stock ledger.
+// Movements are never updated or deleted.
+type StockMovementRepository struct {
+	db *gorm.DB
+}
+
+// NewStockMovementRepository creates a new stock movement repository
+func NewStockMovementRepository(db *gorm.DB) *StockMovementRepository {
+	return &StockMovementRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *StockMovementRepository) WithTx(tx *gorm.DB) *StockMovementRepository {
+	return &StockMovementRepository{db: tx}
+}
+
+// Create appends a movement to the ledger
+func (r *StockMovementRepository) Create(movement *models.StockMovement) error {
+	return r.db.Create(movement).Error
+}
+
+// FindByItemID lists an item's movements, newest first, optionally of one type
+func (r *StockMovementRepository) FindByItemID(itemID uuid.UUID, movementType *models.StockMovementType, limit, offset int) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+	err := r.itemQuery(itemID, movementType).Preload("Actor").
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&movements).Error
+	return movements, err
+}
+
+// CountByItemID counts an item's movements, optionally of one type
+func (r *StockMovementRepository) CountByItemID(itemID uuid.UUID, movementType *models.StockMovementType) (int64, error) {
+	var count int64
+	err := r.itemQuery(itemID, movementType).Count(&count).Error
+	return count, err
+}
+
+// LotBalance is the quantity of one lot still out with an order
+type LotBalance struct {
+	LotID    uuid.UUID
+	Quantity int
+}
+
+// OutstandingByLot returns, per lot, how much of an item an order has taken and
+// not yet returned
+func (r *StockMovementRepository) OutstandingByLot(orderID, itemID uuid.UUID) ([]LotBalance, error) {
+	var balances []LotBalance
+	err := r.db.Model(&models.StockMovement{}).
+		Select("lot_id, -SUM(quantity) AS quantity").
+		Where("order_id = ? AND item_id = ? AND lot_id IS NOT NULL AND type IN ?",
+			orderID, itemID, []models.StockMovementType{models.StockDistribute, models.StockReturn}).
+		Group("lot_id").
+		Having("SUM(quantity) < 0").
+		Scan(&balances).Error
+	return balances, err
+}
+
+// itemQuery selects the movements of an item
+func (r *StockMovementRepository) itemQuery(itemID uuid.UUID, movementType *models.StockMovementType) *gorm.DB {
+	query := r.db.Model(&models.StockMovement{}).Where("item_id = ?", itemID)
+	if movementType != nil {
+		query = query.Where("type = ?", *movementType)
+	}
+	return query
+}
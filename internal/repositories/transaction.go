@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+)
+
+// TxManager runs units of work inside a database transaction
+type TxManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager creates a new transaction manager
+func NewTxManager(db *gorm.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// Transaction executes fn inside a transaction, committing when fn returns nil
+// and rolling back otherwise
+func (m *TxManager) Transaction(fn func(tx *gorm.DB) error) error {
+	return m.db.Transaction(fn)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository handles database operations for users
@@ -18,6 +19,11 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
 // Create creates a new user
 func (r *UserRepository) Create(user *models.User) error {
 	return r.db.Create(user).Error
@@ -36,6 +42,23 @@ func (r *UserRepository) FindByID(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+// LockByID locks a user's row until the surrounding transaction ends. It is
+// used to serialize work that must see all of a user's orders, such as checkout.
+func (r *UserRepository) LockByID(id uuid.UUID) error {
+	var user models.User
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("user not found")
+	}
+	return err
+}
+
+// IncrementNoShowCount records a missed pickup for a user
+func (r *UserRepository) IncrementNoShowCount(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).
+		UpdateColumn("no_show_count", gorm.Expr("no_show_count + 1")).Error
+}
+
 // FindByEmail finds a user by email
 func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 	var user models.User
@@ -66,6 +89,15 @@ func (r *UserRepository) List(limit, offset int) ([]models.User, error) {
 	return users, err
 }
 
+// FindStaffByPantry finds the admins who work at a pantry, sorted by name
+func (r *UserRepository) FindStaffByPantry(pantryID uuid.UUID) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("role = ? AND pantry_id = ?", models.RoleAdmin, pantryID).
+		Order("last_name, first_name, id").
+		Find(&users).Error
+	return users, err
+}
+
 // EmailExists checks if an email already exists
 func (r *UserRepository) EmailExists(email string) (bool, error) {
 	var count int64
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VisitLimitRepository handles database operations for visit limit policies
+type VisitLimitRepository struct {
+	db *gorm.DB
+}
+
+// NewVisitLimitRepository creates a new visit limit repository
+func NewVisitLimitRepository(db *gorm.DB) *VisitLimitRepository {
+	return &VisitLimitRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *VisitLimitRepository) WithTx(tx *gorm.DB) *VisitLimitRepository {
+	return &VisitLimitRepository{db: tx}
+}
+
+// Create creates a new visit limit policy
+func (r *VisitLimitRepository) Create(policy *models.VisitLimitPolicy) error {
+	return r.db.Create(policy).Error
+}
+
+// FindByID finds a visit limit policy by ID
+func (r *VisitLimitRepository) FindByID(id uuid.UUID) (*models.VisitLimitPolicy, error) {
+	var policy models.VisitLimitPolicy
+	err := r.db.First(&policy, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("visit limit policy not found")
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// FindByPantryID finds all visit limit policies for a pantry
+func (r *VisitLimitRepository) FindByPantryID(pantryID uuid.UUID) ([]models.VisitLimitPolicy, error) {
+	var policies []models.VisitLimitPolicy
+	err := r.db.Where("pantry_id = ?", pantryID).
+		Order("window_days ASC").
+		Find(&policies).Error
+	return policies, err
+}
+
+// FindActiveByPantryID finds the active visit limit policies for a pantry
+func (r *VisitLimitRepository) FindActiveByPantryID(pantryID uuid.UUID) ([]models.VisitLimitPolicy, error) {
+	var policies []models.VisitLimitPolicy
+	err := r.db.Where("pantry_id = ? AND is_active = ?", pantryID, true).
+		Order("window_days ASC").
+		Find(&policies).Error
+	return policies, err
+}
+
+// Update updates a visit limit policy
+func (r *VisitLimitRepository) Update(policy *models.VisitLimitPolicy) error {
+	return r.db.Save(policy).Error
+}
+
+// Delete deletes a visit limit policy
+func (r *VisitLimitRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.VisitLimitPolicy{}, "id = ?", id).Error
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WorkflowRepository handles database operations for order workflows
+type WorkflowRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkflowRepository creates a new workflow repository
+func NewWorkflowRepository(db *gorm.DB) *WorkflowRepository {
+	return &WorkflowRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *WorkflowRepository) WithTx(tx *gorm.DB) *WorkflowRepository {
+	return &WorkflowRepository{db: tx}
+}
+
+// Create creates a workflow together with its states and transitions
+func (r *WorkflowRepository) Create(workflow *models.OrderWorkflow) error {
+	return r.db.Create(workflow).Error
+}
+
+// FindDefault finds the default workflow for a fulfillment type, shared by pantries without their own
+func (r *WorkflowRepository) FindDefault(fulfillment models.FulfillmentType) (*models.OrderWorkflow, error) {
+	var workflow models.OrderWorkflow
+	err := r.db.Preload("States").Preload("Transitions").
+		First(&workflow, "pantry_id IS NULL AND fulfillment_type = ?", fulfillment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("default workflow not found")
+		}
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// FindByPantryID finds the workflow a pantry defined for a fulfillment type, returning nil if it has none
+func (r *WorkflowRepository) FindByPantryID(pantryID uuid.UUID, fulfillment models.FulfillmentType) (*models.OrderWorkflow, error) {
+	var workflow models.OrderWorkflow
+	err := r.db.Preload("States").Preload("Transitions").
+		First(&workflow, "pantry_id = ? AND fulfillment_type = ?", pantryID, fulfillment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// FindForPantry finds the workflow in effect for a pantry and fulfillment type,
+// falling back to the default
+func (r *WorkflowRepository) FindForPantry(pantryID uuid.UUID, fulfillment models.FulfillmentType) (*models.OrderWorkflow, error) {
+	workflow, err := r.FindByPantryID(pantryID, fulfillment)
+	if err != nil {
+		return nil, err
+	}
+	if workflow != nil {
+		return workflow, nil
+	}
+	return r.FindDefault(fulfillment)
+}
+
+// Delete deletes a workflow with its states and transitions
+func (r *WorkflowRepository) Delete(id uuid.UUID) error {
+	if err := r.db.Where("workflow_id = ?", id).Delete(&models.WorkflowTransition{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("workflow_id = ?", id).Delete(&models.WorkflowState{}).Error; err != nil {
+		return err
+	}
+	return r.db.Delete(&models.OrderWorkflow{}, "id = ?", id).Error
+}
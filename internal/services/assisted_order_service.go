@@ -0,0 +1,242 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AssistedOrderService lets staff place orders for clients who cannot use the
+// app themselves, either on behalf of an existing client or for a walk-in
+// visitor without an account
+type AssistedOrderService struct {
+	cartRepo       *repositories.CartRepository
+	itemRepo       *repositories.ItemRepository
+	orderRepo      *repositories.OrderRepository
+	userRepo       *repositories.UserRepository
+	pantryRepo     *repositories.PantryRepository
+	slotRepo       *repositories.PickupSlotRepository
+	eventRepo      *repositories.OrderEventRepository
+	workflowRepo   *repositories.WorkflowRepository
+	visitLimitRepo *repositories.VisitLimitRepository
+	stockService   *StockService
+	txManager      *repositories.TxManager
+	staffService   *StaffService
+}
+
+// NewAssistedOrderService creates a new assisted order service
+func NewAssistedOrderService(cartRepo *repositories.CartRepository, itemRepo *repositories.ItemRepository, orderRepo *repositories.OrderRepository, userRepo *repositories.UserRepository, pantryRepo *repositories.PantryRepository, slotRepo *repositories.PickupSlotRepository, eventRepo *repositories.OrderEventRepository, workflowRepo *repositories.WorkflowRepository, visitLimitRepo *repositories.VisitLimitRepository, stockService *StockService, txManager *repositories.TxManager, staffService *StaffService) *AssistedOrderService {
+	return &AssistedOrderService{
+		cartRepo:       cartRepo,
+		itemRepo:       itemRepo,
+		orderRepo:      orderRepo,
+		userRepo:       userRepo,
+		pantryRepo:     pantryRepo,
+		slotRepo:       slotRepo,
+		eventRepo:      eventRepo,
+		workflowRepo:   workflowRepo,
+		visitLimitRepo: visitLimitRepo,
+		stockService:   stockService,
+		txManager:      txManager,
+		staffService:   staffService,
+	}
+}
+
+// AssistedOrderLine is one item of a staff-built basket
+type AssistedOrderLine struct {
+	ItemID   uuid.UUID `json:"item_id" binding:"required"`
+	Quantity int       `json:"quantity" binding:"required,min=1"`
+}
+
+// AssistedOrderDetails holds the basket and fulfillment of a staff-placed order
+type AssistedOrderDetails struct {
+	PantryID        uuid.UUID              `json:"pantry_id" binding:"required"`
+	Items           []AssistedOrderLine    `json:"items" binding:"required,min=1,dive"`
+	Notes           string                 `json:"notes"`
+	FulfillmentType models.FulfillmentType `json:"fulfillment_type"` // Defaults to pickup
+	PickupSlotID    *uuid.UUID             `json:"pickup_slot_id"`
+	Delivery        *DeliveryDetails       `json:"delivery"` // Required for delivery orders
+}
+
+// ClientOrderRequest represents an order placed by staff on behalf of a client
+type ClientOrderRequest struct {
+	ClientID uuid.UUID `json:"client_id" binding:"required"`
+	AssistedOrderDetails
+}
+
+// WalkInOrderRequest represents an order placed by staff for a visitor without an account
+type WalkInOrderRequest struct {
+	Name          string `json:"name" binding:"required"`
+	HouseholdSize int    `json:"household_size" binding:"omitempty,min=1"` // Defaults to 1
+	AssistedOrderDetails
+}
+
+// CreateClientOrder places an order for an existing client. The client's visit
+// limits and household size apply as if they had checked out themselves.
+func (s *AssistedOrderService) CreateClientOrder(staffID uuid.UUID, req *ClientOrderRequest) (*models.Order, error) {
+	client, err := s.userRepo.FindByID(req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client.Role != models.RoleUser {
+		return nil, errors.New("orders can only be placed for client accounts")
+	}
+
+	order := &models.Order{UserID: &client.ID}
+	return s.create(staffID, order, client.HouseholdSize, &req.AssistedOrderDetails)
+}
+
+// CreateWalkInOrder places an order for a walk-in visitor identified only by name
+func (s *AssistedOrderService) CreateWalkInOrder(staffID uuid.UUID, req *WalkInOrderRequest) (*models.Order, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	householdSize := req.HouseholdSize
+	if householdSize < 1 {
+		householdSize = 1
+	}
+
+	order := &models.Order{
+		WalkInName:          name,
+		WalkInHouseholdSize: householdSize,
+	}
+	return s.create(staffID, order, householdSize, &req.AssistedOrderDetails)
+}
+
+// create builds the basket from the requested items and places the order with
+// the same limit checks and inventory decrement as a client checkout
+func (s *AssistedOrderService) create(staffID uuid.UUID, order *models.Order, householdSize int, req *AssistedOrderDetails) (*models.Order, error) {
+	pantry, err := s.pantryRepo.FindByID(req.PantryID)
+	if err != nil {
+		return nil, err
+	}
+	if !pantry.IsActive {
+		return nil, errors.New("pantry is not active")
+	}
+
+	order.ID = uuid.New() // Known up front so stock movements can refer to it
+	order.PantryID = pantry.ID
+	order.Notes = req.Notes
+	order.CreatedByID = &staffID
+	checkout := &CheckoutRequest{
+		FulfillmentType: req.FulfillmentType,
+		PickupSlotID:    req.PickupSlotID,
+		Delivery:        req.Delivery,
+	}
+	if err := applyFulfillment(order, pantry, checkout); err != nil {
+		return nil, err
+	}
+
+	// Merge repeated items into one line each
+	var lines []models.CartItem
+	for _, requested := range req.Items {
+		merged := false
+		for i := range lines {
+			if lines[i].ItemID == requested.ItemID {
+				lines[i].Quantity += requested.Quantity
+				merged = true
+				break
+			}
+		}
+		if merged {
+			continue
+		}
+
+		item, err := s.itemRepo.FindByID(requested.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		if item.PantryID != pantry.ID {
+			return nil, errors.New("item does not belong to this pantry: " + item.Name)
+		}
+		if !item.IsAvailable {
+			return nil, errors.New("item is not available: " + item.Name)
+		}
+		lines = append(lines, models.CartItem{ItemID: item.ID, Item: *item, Quantity: requested.Quantity})
+	}
+	if err := checkQuantityLimits(lines, householdSize); err != nil {
+		return nil, err
+	}
+
+	workflow, err := s.workflowRepo.FindForPantry(pantry.ID, order.FulfillmentType)
+	if err != nil {
+		return nil, err
+	}
+	order.Status = workflow.InitialStatus
+
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		if !order.IsWalkIn() {
+			// Lock the client so a concurrent checkout of their own is counted
+			if err := s.userRepo.WithTx(tx).LockByID(*order.UserID); err != nil {
+				return err
+			}
+			if err := checkVisitLimits(s.visitLimitRepo.WithTx(tx), s.orderRepo.WithTx(tx), *order.UserID, pantry); err != nil {
+				return err
+			}
+		}
+
+		ref := models.StockMovement{ActorID: &staffID, OrderID: &order.ID}
+		if err := takeStock(tx, s.stockService, lines, ref); err != nil {
+			return err
+		}
+
+		// The basket is stored as an already submitted cart, like a checked out one
+		cartRepo := s.cartRepo.WithTx(tx)
+		cart := &models.Cart{
+			UserID:   order.UserID,
+			PantryID: pantry.ID,
+			Status:   models.CartStatusSubmitted,
+		}
+		if err := cartRepo.Create(cart); err != nil {
+			return err
+		}
+		for _, line := range lines {
+			err := cartRepo.AddItem(&models.CartItem{
+				CartID:            cart.ID,
+				ItemID:            line.ItemID,
+				Quantity:          line.Quantity,
+				RequestedQuantity: line.Quantity,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		order.CartID = cart.ID
+
+		if req.PickupSlotID != nil {
+			if err := bookSlot(s.slotRepo.WithTx(tx), order, *req.PickupSlotID); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if order.FulfillmentType == models.FulfillmentPickup {
+			err = createWithPickupCode(tx, s.orderRepo, order)
+		} else {
+			err = s.orderRepo.WithTx(tx).Create(order)
+		}
+		if err != nil {
+			return err
+		}
+		err = s.eventRepo.WithTx(tx).Create(&models.OrderEvent{
+			OrderID:  order.ID,
+			Type:     models.OrderEventCreated,
+			ActorID:  &staffID,
+			ToStatus: order.Status,
+		})
+		if err != nil {
+			return err
+		}
+		return s.staffService.AutoAssign(tx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.orderRepo.FindByID(order.ID)
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// attemptLimiter counts failed attempts per key over a sliding window and
+// blocks a key once it reaches the maximum. State is kept in process memory,
+// so it is not shared between instances and is lost on restart.
+type attemptLimiter struct {
+	mu       sync.Mutex
+	max      int
+	window   time.Duration
+	failures map[string][]time.Time
+}
+
+// newAttemptLimiter creates a limiter allowing max failures per window
+func newAttemptLimiter(max int, window time.Duration) *attemptLimiter {
+	return &attemptLimiter{
+		max:      max,
+		window:   window,
+		failures: make(map[string][]time.Time),
+	}
+}
+
+// Allow reports whether the key may make another attempt
+func (l *attemptLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.prune(key)) < l.max
+}
+
+// Fail records a failed attempt for the key
+func (l *attemptLimiter) Fail(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failures[key] = append(l.prune(key), time.Now())
+}
+
+// prune drops failures older than the window and returns the rest
+func (l *attemptLimiter) prune(key string) []time.Time {
+	cutoff := time.Now().Add(-l.window)
+	recent := l.failures[key][:0]
+	for _, t := range l.failures[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) == 0 {
+		delete(l.failures, key)
+		return nil
+	}
+	l.failures[key] = recent
+	return recent
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAttemptLimiterBlocksAtMax(t *testing.T) {
+	l := newAttemptLimiter(3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if !l.Allow("staff") {
+			t.Fatalf("Allow after %d failures = false, want true", i)
+		}
+		l.Fail("staff")
+	}
+	if l.Allow("staff") {
+		t.Error("Allow after 3 failures = true, want false")
+	}
+	if !l.Allow("other") {
+		t.Error("Allow for another key = false, want true")
+	}
+}
+
+func TestAttemptLimiterWindowExpires(t *testing.T) {
+	l := newAttemptLimiter(2, 50*time.Millisecond)
+	l.Fail("staff")
+	l.Fail("staff")
+	if l.Allow("staff") {
+		t.Fatal("Allow after 2 failures = true, want false")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !l.Allow("staff") {
+		t.Error("Allow after the window passed = false, want true")
+	}
+	if _, ok := l.failures["staff"]; ok {
+		t.Error("expired failures were not pruned")
+	}
+}
+
+func TestAttemptLimiterSlidingWindow(t *testing.T) {
+	l := newAttemptLimiter(2, 100*time.Millisecond)
+	l.Fail("staff")
+	time.Sleep(60 * time.Millisecond)
+	l.Fail("staff")
+	if l.Allow("staff") {
+		t.Fatal("Allow after 2 failures = true, want false")
+	}
+
+	// Only the first failure has left the window
+	time.Sleep(60 * time.Millisecond)
+	if !l.Allow("staff") {
+		t.Error("Allow after the oldest failure expired = false, want true")
+	}
+	if got := len(l.failures["staff"]); got != 1 {
+		t.Errorf("failures in window = %d, want 1", got)
+	}
+}
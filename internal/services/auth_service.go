@@ -31,6 +31,8 @@ type RegisterRequest struct {
 	LastName  string     `json:"last_name" binding:"required"`
 	Phone     string     `json:"phone"`
 	PantryID  *uuid.UUID `json:"pantry_id"`
+
+	HouseholdSize int `json:"household_size" binding:"omitempty,min=1"`
 }
 
 // LoginRequest represents a login request
@@ -63,14 +65,20 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 	}
 
 	// Create user
+	householdSize := req.HouseholdSize
+	if householdSize == 0 {
+		householdSize = 1
+	}
+
 	user := &models.User{
-		Email:        req.Email,
-		PasswordHash: hashedPassword,
-		FirstName:    req.FirstName,
-		LastName:     req.LastName,
-		Phone:        req.Phone,
-		Role:         models.RoleUser,
-		PantryID:     req.PantryID,
+		Email:         req.Email,
+		PasswordHash:  hashedPassword,
+		FirstName:     req.FirstName,
+		LastName:      req.LastName,
+		Phone:         req.Phone,
+		Role:          models.RoleUser,
+		PantryID:      req.PantryID,
+		HouseholdSize: householdSize,
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
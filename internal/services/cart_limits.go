@@ -0,0 +1,192 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/google/uuid"
+)
+
+// QuantityLimitError is returned when a cart would exceed a per-order item or
+// category limit
+type QuantityLimitError struct {
+	Violations []string
+}
+
+func (e *QuantityLimitError) Error() string {
+	return "order limit exceeded for: " + strings.Join(e.Violations, ", ")
+}
+
+// ItemAllowance reports how much more of an item fits in the order.
+// Limit and Remaining are nil when the item is unlimited.
+type ItemAllowance struct {
+	ItemID    uuid.UUID `json:"item_id"`
+	Name      string    `json:"name"`
+	InCart    int       `json:"in_cart"`
+	Limit     *int      `json:"limit"`
+	Remaining *int      `json:"remaining"`
+}
+
+// CategoryAllowance reports how much more of a capped category fits in the order
+type CategoryAllowance struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Name       string    `json:"name"`
+	InCart     int       `json:"in_cart"`
+	Limit      int       `json:"limit"`
+	Remaining  int       `json:"remaining"`
+}
+
+// CartAllowances holds the remaining per-order allowances of a cart
+type CartAllowances struct {
+	Items      []ItemAllowance     `json:"items"`
+	Categories []CategoryAllowance `json:"categories"`
+}
+
+// effectiveLimit returns the per-order limit for a household, or 0 if unlimited
+func effectiveLimit(maxPerOrder int, scaleByHousehold bool, householdSize int) int {
+	if maxPerOrder <= 0 {
+		return 0
+	}
+	if scaleByHousehold && householdSize > 1 {
+		return maxPerOrder * householdSize
+	}
+	return maxPerOrder
+}
+
+// computeAllowances evaluates the item and category limits against the given
+// cart lines, whose items must have their category loaded. Capped categories
+// passed in are reported even when the cart holds none of their items.
+func computeAllowances(lines []models.CartItem, categories []models.Category, householdSize int) *CartAllowances {
+	allowances := &CartAllowances{
+		Items:      []ItemAllowance{},
+		Categories: []CategoryAllowance{},
+	}
+
+	itemTotals := make(map[uuid.UUID]int)
+	categoryTotals := make(map[uuid.UUID]int)
+	items := make(map[uuid.UUID]models.Item)
+	capped := make(map[uuid.UUID]models.Category)
+
+	for _, category := range categories {
+		if category.MaxPerOrder > 0 {
+			capped[category.ID] = category
+		}
+	}
+	for _, line := range lines {
+		if _, ok := items[line.ItemID]; !ok {
+			items[line.ItemID] = line.Item
+		}
+		itemTotals[line.ItemID] += line.Quantity
+		categoryTotals[line.Item.CategoryID] += line.Quantity
+		if line.Item.Category.MaxPerOrder > 0 {
+			capped[line.Item.CategoryID] = line.Item.Category
+		}
+	}
+
+	for itemID, inCart := range itemTotals {
+		item := items[itemID]
+		allowance := ItemAllowance{ItemID: itemID, Name: item.Name, InCart: inCart}
+		if limit := effectiveLimit(item.MaxPerOrder, item.ScaleLimitByHousehold, householdSize); limit > 0 {
+			remaining := limit - inCart
+			allowance.Limit = &limit
+			allowance.Remaining = &remaining
+		}
+		allowances.Items = append(allowances.Items, allowance)
+	}
+
+	for categoryID, category := range capped {
+		limit := effectiveLimit(category.MaxPerOrder, category.ScaleLimitByHousehold, householdSize)
+		inCart := categoryTotals[categoryID]
+		allowances.Categories = append(allowances.Categories, CategoryAllowance{
+			CategoryID: categoryID,
+			Name:       category.Name,
+			InCart:     inCart,
+			Limit:      limit,
+			Remaining:  limit - inCart,
+		})
+	}
+
+	sort.Slice(allowances.Items, func(i, j int) bool {
+		return allowances.Items[i].Name < allowances.Items[j].Name
+	})
+	sort.Slice(allowances.Categories, func(i, j int) bool {
+		return allowances.Categories[i].Name < allowances.Categories[j].Name
+	})
+
+	return allowances
+}
+
+// checkQuantityLimits returns a QuantityLimitError if the cart lines exceed
+// any item or category limit
+func checkQuantityLimits(lines []models.CartItem, householdSize int) error {
+	allowances := computeAllowances(lines, nil, householdSize)
+
+	var violations []string
+	for _, item := range allowances.Items {
+		if item.Remaining != nil && *item.Remaining < 0 {
+			violations = append(violations, fmt.Sprintf("%s (max %d per order)", item.Name, *item.Limit))
+		}
+	}
+	for _, category := range allowances.Categories {
+		if category.Remaining < 0 {
+			violations = append(violations, fmt.Sprintf("%s (max %d per order)", category.Name, category.Limit))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &QuantityLimitError{Violations: violations}
+	}
+	return nil
+}
+
+// withLineQuantity returns a copy of the cart lines with the item's quantity
+// set, adding a line for it if the cart does not contain it yet
+func withLineQuantity(lines []models.CartItem, item *models.Item, quantity int) []models.CartItem {
+	result := make([]models.CartItem, 0, len(lines)+1)
+	found := false
+	for _, line := range lines {
+		if line.ItemID == item.ID {
+			line.Quantity = quantity
+			found = true
+		}
+		result = append(result, line)
+	}
+	if !found {
+		result = append(result, models.CartItem{ItemID: item.ID, Item: *item, Quantity: quantity})
+	}
+	return result
+}
+
+// remainingAllowance returns how many more of the item fit next to the cart
+// lines under its item and category limits, or -1 if neither limits it. The
+// item must have its category loaded.
+func remainingAllowance(lines []models.CartItem, item *models.Item, householdSize int) int {
+	itemTotal, categoryTotal := 0, 0
+	for _, line := range lines {
+		if line.ItemID == item.ID {
+			itemTotal += line.Quantity
+		}
+		if line.Item.CategoryID == item.CategoryID {
+			categoryTotal += line.Quantity
+		}
+	}
+
+	remaining, limited := 0, false
+	if limit := effectiveLimit(item.MaxPerOrder, item.ScaleLimitByHousehold, householdSize); limit > 0 {
+		remaining, limited = limit-itemTotal, true
+	}
+	if limit := effectiveLimit(item.Category.MaxPerOrder, item.Category.ScaleLimitByHousehold, householdSize); limit > 0 {
+		if left := limit - categoryTotal; !limited || left < remaining {
+			remaining, limited = left, true
+		}
+	}
+	if !limited {
+		return -1
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
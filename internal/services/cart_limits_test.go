@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestCheckQuantityLimits(t *testing.T) {
+	produce := models.Category{ID: uuid.New(), Name: "Produce", MaxPerOrder: 5}
+	dairy := models.Category{ID: uuid.New(), Name: "Dairy", MaxPerOrder: 2, ScaleLimitByHousehold: true}
+	pantry := models.Category{ID: uuid.New(), Name: "Pantry"}
+
+	item := func(name string, category models.Category, maxPerOrder int, scale bool) models.Item {
+		return models.Item{
+			ID: uuid.New(), Name: name, CategoryID: category.ID, Category: category,
+			MaxPerOrder: maxPerOrder, ScaleLimitByHousehold: scale,
+		}
+	}
+	apples := item("Apples", produce, 3, false)
+	carrots := item("Carrots", produce, 0, false)
+	milk := item("Milk", dairy, 0, false)
+	rice := item("Rice", pantry, 1, true)
+	beans := item("Beans", pantry, 0, false)
+
+	line := func(item models.Item, quantity int) models.CartItem {
+		return models.CartItem{ItemID: item.ID, Item: item, Quantity: quantity}
+	}
+
+	tests := []struct {
+		name          string
+		lines         []models.CartItem
+		householdSize int
+		want          []string // Nil when the cart is within its limits
+	}{
+		{
+			name:          "empty cart",
+			householdSize: 1,
+		},
+		{
+			name:          "unlimited item in an uncapped category",
+			lines:         []models.CartItem{line(beans, 500)},
+			householdSize: 1,
+		},
+		{
+			name:          "item at its limit",
+			lines:         []models.CartItem{line(apples, 3)},
+			householdSize: 1,
+		},
+		{
+			name:          "item over its limit",
+			lines:         []models.CartItem{line(apples, 4)},
+			householdSize: 1,
+			want:          []string{"Apples (max 3 per order)"},
+		},
+		{
+			name:          "item limit ignores household size unless scaled",
+			lines:         []models.CartItem{line(apples, 4)},
+			householdSize: 4,
+			want:          []string{"Apples (max 3 per order)"},
+		},
+		{
+			name:          "item limit scaled by household size",
+			lines:         []models.CartItem{line(rice, 3)},
+			householdSize: 3,
+		},
+		{
+			name:          "item over its scaled limit",
+			lines:         []models.CartItem{line(rice, 4)},
+			householdSize: 3,
+			want:          []string{"Rice (max 3 per order)"},
+		},
+		{
+			name:          "household size of zero counts as one",
+			lines:         []models.CartItem{line(rice, 2)},
+			householdSize: 0,
+			want:          []string{"Rice (max 1 per order)"},
+		},
+		{
+			name:          "category total over its limit",
+			lines:         []models.CartItem{line(apples, 3), line(carrots, 3)},
+			householdSize: 1,
+			want:          []string{"Produce (max 5 per order)"},
+		},
+		{
+			name:          "split lines of one item add up",
+			lines:         []models.CartItem{line(apples, 2), line(apples, 2)},
+			householdSize: 1,
+			want:          []string{"Apples (max 3 per order)"},
+		},
+		{
+			name:          "category limit scaled by household size",
+			lines:         []models.CartItem{line(milk, 4)},
+			householdSize: 2,
+		},
+		{
+			name:          "item and category violations together",
+			lines:         []models.CartItem{line(apples, 4), line(carrots, 2), line(milk, 5), line(rice, 2)},
+			householdSize: 2,
+			want: []string{
+				"Apples (max 3 per order)",
+				"Dairy (max 4 per order)",
+				"Produce (max 5 per order)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkQuantityLimits(tt.lines, tt.householdSize)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("checkQuantityLimits returned error: %v", err)
+				}
+				return
+			}
+
+			var limitErr *QuantityLimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("checkQuantityLimits = %v, want a QuantityLimitError", err)
+			}
+			if !reflect.DeepEqual(limitErr.Violations, tt.want) {
+				t.Errorf("Violations = %q, want %q", limitErr.Violations, tt.want)
+			}
+		})
+	}
+}
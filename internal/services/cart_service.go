@@ -1,42 +1,116 @@
 package services
 
 import (
+	"bytes"
 	"errors"
+	"sort"
+	"strings"
 
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/byte4bite/byte4bite/internal/repositories"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // CartService handles cart business logic
 type CartService struct {
-	cartRepo *repositories.CartRepository
-	itemRepo *repositories.ItemRepository
+	cartRepo       *repositories.CartRepository
+	itemRepo       *repositories.ItemRepository
+	categoryRepo   *repositories.CategoryRepository
+	orderRepo      *repositories.OrderRepository
+	userRepo       *repositories.UserRepository
+	slotRepo       *repositories.PickupSlotRepository
+	eventRepo      *repositories.OrderEventRepository
+	workflowRepo   *repositories.WorkflowRepository
+	visitLimitRepo *repositories.VisitLimitRepository
+	stockService   *StockService
+	txManager      *repositories.TxManager
+	staffService   *StaffService
+	holdService    *StockHoldService
 }
 
 // NewCartService creates a new cart service
-func NewCartService(cartRepo *repositories.CartRepository, itemRepo *repositories.ItemRepository) *CartService {
+func NewCartService(cartRepo *repositories.CartRepository, itemRepo *repositories.ItemRepository, categoryRepo *repositories.CategoryRepository, orderRepo *repositories.OrderRepository, userRepo *repositories.UserRepository, slotRepo *repositories.PickupSlotRepository, eventRepo *repositories.OrderEventRepository, workflowRepo *repositories.WorkflowRepository, visitLimitRepo *repositories.VisitLimitRepository, stockService *StockService, txManager *repositories.TxManager, staffService *StaffService, holdService *StockHoldService) *CartService {
 	return &CartService{
-		cartRepo: cartRepo,
-		itemRepo: itemRepo,
+		cartRepo:       cartRepo,
+		itemRepo:       itemRepo,
+		categoryRepo:   categoryRepo,
+		orderRepo:      orderRepo,
+		userRepo:       userRepo,
+		slotRepo:       slotRepo,
+		eventRepo:      eventRepo,
+		workflowRepo:   workflowRepo,
+		visitLimitRepo: visitLimitRepo,
+		stockService:   stockService,
+		txManager:      txManager,
+		staffService:   staffService,
+		holdService:    holdService,
 	}
 }
 
+// InsufficientStockError is returned by Checkout when one or more items
+// ran out before the order could be placed
+type InsufficientStockError struct {
+	Items []string
+}
+
+func (e *InsufficientStockError) Error() string {
+	return "insufficient quantity for: " + strings.Join(e.Items, ", ")
+}
+
+// ErrPantryRequired is returned when a client with carts at several pantries
+// does not say which one to use
+var ErrPantryRequired = errors.New("you have carts at several pantries; select one with pantry_id")
+
+// errInsufficientQuantity is returned when an item's unheld stock cannot cover a cart line
+var errInsufficientQuantity = errors.New("insufficient quantity available")
+
+// errCartNotActive is returned when a cart was checked out or cancelled while
+// being changed
+var errCartNotActive = errors.New("cart is no longer active")
+
 // AddItemRequest represents a request to add an item to cart
 type AddItemRequest struct {
 	ItemID   uuid.UUID `json:"item_id" binding:"required"`
 	Quantity int       `json:"quantity" binding:"required,min=1"`
 }
 
+// CheckoutRequest represents a request to convert the cart into an order
+type CheckoutRequest struct {
+	Notes           string                 `json:"notes"`
+	FulfillmentType models.FulfillmentType `json:"fulfillment_type"` // Defaults to pickup
+	PickupSlotID    *uuid.UUID             `json:"pickup_slot_id"`
+	Delivery        *DeliveryDetails       `json:"delivery"` // Required for delivery orders
+}
+
 // UpdateCartItemRequest represents a request to update cart item quantity
 type UpdateCartItemRequest struct {
 	Quantity int `json:"quantity" binding:"required,min=0"`
 }
 
-// GetOrCreateCart gets the active cart for a user or creates a new one
+// ReorderLine reports what happened to one item of a reordered order
+type ReorderLine struct {
+	ItemID    uuid.UUID `json:"item_id"`
+	Name      string    `json:"name"`
+	Requested int       `json:"requested"`
+	Added     int       `json:"added"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// ReorderResult is the cart built from a previous order and what was added,
+// reduced or skipped on the way
+type ReorderResult struct {
+	Cart    *models.Cart  `json:"cart"`
+	Added   []ReorderLine `json:"added"`
+	Reduced []ReorderLine `json:"reduced"`
+	Skipped []ReorderLine `json:"skipped"`
+}
+
+// GetOrCreateCart gets the user's active cart at a pantry or creates a new one.
+// Clients keep one active cart per pantry.
 func (s *CartService) GetOrCreateCart(userID, pantryID uuid.UUID) (*models.Cart, error) {
 	// Try to find active cart
-	cart, err := s.cartRepo.FindActiveByUserID(userID)
+	cart, err := s.cartRepo.FindActiveByUserAndPantry(userID, pantryID)
 	if err != nil {
 		return nil, err
 	}
@@ -44,7 +118,7 @@ func (s *CartService) GetOrCreateCart(userID, pantryID uuid.UUID) (*models.Cart,
 	// If no active cart exists, create one
 	if cart == nil {
 		cart = &models.Cart{
-			UserID:   userID,
+			UserID:   &userID,
 			PantryID: pantryID,
 			Status:   models.CartStatusActive,
 		}
@@ -67,13 +141,65 @@ func (s *CartService) GetCart(cartID uuid.UUID) (*models.Cart, error) {
 	return s.cartRepo.FindByID(cartID)
 }
 
-// GetCurrentCart gets the current active cart for a user
-func (s *CartService) GetCurrentCart(userID uuid.UUID) (*models.Cart, error) {
+// GetCurrentCart gets the user's active cart at the selected pantry. Without a
+// selection it is the user's only active cart; ErrPantryRequired is returned
+// if they have several. Returns nil if there is none.
+func (s *CartService) GetCurrentCart(userID uuid.UUID, pantryID *uuid.UUID) (*models.Cart, error) {
+	if pantryID != nil {
+		return s.cartRepo.FindActiveByUserAndPantry(userID, *pantryID)
+	}
+
+	carts, err := s.cartRepo.FindActiveByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	switch len(carts) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &carts[0], nil
+	default:
+		return nil, ErrPantryRequired
+	}
+}
+
+// GetActiveCarts gets the user's active carts at all pantries
+func (s *CartService) GetActiveCarts(userID uuid.UUID) ([]models.Cart, error) {
 	return s.cartRepo.FindActiveByUserID(userID)
 }
 
-// AddItem adds an item to the cart
-func (s *CartService) AddItem(userID, pantryID uuid.UUID, req *AddItemRequest) (*models.Cart, error) {
+// findCartWithLine finds the user's active cart that holds the given cart item
+func (s *CartService) findCartWithLine(userID, cartItemID uuid.UUID) (*models.Cart, *models.CartItem, error) {
+	carts, err := s.cartRepo.FindActiveByUserID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(carts) == 0 {
+		return nil, nil, errors.New("no active cart found")
+	}
+	for i := range carts {
+		for j := range carts[i].Items {
+			if carts[i].Items[j].ID == cartItemID {
+				return &carts[i], &carts[i].Items[j], nil
+			}
+		}
+	}
+	return nil, nil, errors.New("cart item not found in your cart")
+}
+
+// GetAllowances reports the remaining per-order allowance of every item in the
+// cart and of every capped category of the cart's pantry
+func (s *CartService) GetAllowances(cart *models.Cart) (*CartAllowances, error) {
+	categories, err := s.categoryRepo.FindByPantryID(cart.PantryID)
+	if err != nil {
+		return nil, err
+	}
+	return computeAllowances(cart.Items, categories, cart.User.HouseholdSize), nil
+}
+
+// AddItem adds an item to the user's cart at the item's pantry and holds its
+// stock for the cart
+func (s *CartService) AddItem(userID uuid.UUID, req *AddItemRequest) (*models.Cart, error) {
 	// Verify item exists and is available
 	item, err := s.itemRepo.FindByID(req.ItemID)
 	if err != nil {
@@ -83,124 +209,138 @@ func (s *CartService) AddItem(userID, pantryID uuid.UUID, req *AddItemRequest) (
 	if !item.IsAvailable {
 		return nil, errors.New("item is not available")
 	}
-
-	if item.Quantity < req.Quantity {
-		return nil, errors.New("insufficient quantity available")
+	if !item.Pantry.IsActive {
+		return nil, errors.New("pantry is not active")
 	}
 
-	// Get or create cart
-	cart, err := s.GetOrCreateCart(userID, pantryID)
+	// Get or create the cart at the item's pantry
+	cart, err := s.GetOrCreateCart(userID, item.PantryID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if item already in cart
-	existingCartItem, err := s.cartRepo.FindCartItem(cart.ID, req.ItemID)
-	if err != nil {
-		return nil, err
-	}
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		cartRepo := s.cartRepo.WithTx(tx)
+		locked, err := lockActiveCart(cartRepo, cart.ID)
+		if err != nil {
+			return err
+		}
 
-	if existingCartItem != nil {
-		// Update quantity
-		newQuantity := existingCartItem.Quantity + req.Quantity
-		if item.Quantity < newQuantity {
-			return nil, errors.New("insufficient quantity available")
+		// Check if item already in cart
+		existingCartItem, err := cartRepo.FindCartItem(cart.ID, req.ItemID)
+		if err != nil {
+			return err
 		}
-		existingCartItem.Quantity = newQuantity
-		if err := s.cartRepo.UpdateItem(existingCartItem); err != nil {
-			return nil, err
+
+		newQuantity := req.Quantity
+		if existingCartItem != nil {
+			newQuantity += existingCartItem.Quantity
+		}
+		if err := checkQuantityLimits(withLineQuantity(locked.Items, item, newQuantity), locked.User.HouseholdSize); err != nil {
+			return err
+		}
+
+		held, err := s.holdService.Set(tx, cart.ID, item.ID, newQuantity)
+		if err != nil {
+			return err
 		}
-	} else {
+		if !held {
+			return errInsufficientQuantity
+		}
+
+		if existingCartItem != nil {
+			// Update quantity
+			existingCartItem.Quantity = newQuantity
+			return cartRepo.UpdateItem(existingCartItem)
+		}
+
 		// Add new item
-		cartItem := &models.CartItem{
+		return cartRepo.AddItem(&models.CartItem{
 			CartID:   cart.ID,
 			ItemID:   req.ItemID,
 			Quantity: req.Quantity,
-		}
-		if err := s.cartRepo.AddItem(cartItem); err != nil {
-			return nil, err
-		}
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Reload cart with updated items
 	return s.cartRepo.FindByID(cart.ID)
 }
 
-// UpdateItemQuantity updates the quantity of an item in the cart
+// UpdateItemQuantity updates the quantity of an item in the cart, adjusting its hold
 func (s *CartService) UpdateItemQuantity(userID, cartItemID uuid.UUID, req *UpdateCartItemRequest) (*models.Cart, error) {
-	// Get user's active cart
-	cart, err := s.cartRepo.FindActiveByUserID(userID)
+	// Find the cart item among the user's active carts
+	cart, cartItem, err := s.findCartWithLine(userID, cartItemID)
 	if err != nil {
 		return nil, err
 	}
-	if cart == nil {
-		return nil, errors.New("no active cart found")
-	}
 
-	// Find the cart item
-	var cartItem *models.CartItem
-	for i := range cart.Items {
-		if cart.Items[i].ID == cartItemID {
-			cartItem = &cart.Items[i]
-			break
-		}
+	// If quantity is 0, remove the item
+	if req.Quantity == 0 {
+		return s.removeLine(cart, cartItem)
 	}
 
-	if cartItem == nil {
-		return nil, errors.New("cart item not found")
+	// Verify item availability
+	item, err := s.itemRepo.FindByID(cartItem.ItemID)
+	if err != nil {
+		return nil, err
 	}
 
-	// If quantity is 0, remove the item
-	if req.Quantity == 0 {
-		if err := s.cartRepo.RemoveItem(cartItemID); err != nil {
-			return nil, err
-		}
-	} else {
-		// Verify item availability
-		item, err := s.itemRepo.FindByID(cartItem.ItemID)
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		cartRepo := s.cartRepo.WithTx(tx)
+		locked, err := lockActiveCart(cartRepo, cart.ID)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := checkQuantityLimits(withLineQuantity(locked.Items, item, req.Quantity), locked.User.HouseholdSize); err != nil {
+			return err
 		}
 
-		if item.Quantity < req.Quantity {
-			return nil, errors.New("insufficient quantity available")
+		held, err := s.holdService.Set(tx, cart.ID, item.ID, req.Quantity)
+		if err != nil {
+			return err
+		}
+		if !held {
+			return errInsufficientQuantity
 		}
 
 		cartItem.Quantity = req.Quantity
-		if err := s.cartRepo.UpdateItem(cartItem); err != nil {
-			return nil, err
-		}
+		return cartRepo.UpdateItem(cartItem)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Reload cart
 	return s.cartRepo.FindByID(cart.ID)
 }
 
-// RemoveItem removes an item from the cart
+// RemoveItem removes an item from the cart and releases its hold
 func (s *CartService) RemoveItem(userID, cartItemID uuid.UUID) (*models.Cart, error) {
-	// Get user's active cart
-	cart, err := s.cartRepo.FindActiveByUserID(userID)
+	// Verify cart item belongs to one of the user's carts
+	cart, cartItem, err := s.findCartWithLine(userID, cartItemID)
 	if err != nil {
 		return nil, err
 	}
-	if cart == nil {
-		return nil, errors.New("no active cart found")
-	}
-
-	// Verify cart item belongs to this cart
-	var found bool
-	for _, item := range cart.Items {
-		if item.ID == cartItemID {
-			found = true
-			break
-		}
-	}
 
-	if !found {
-		return nil, errors.New("cart item not found in your cart")
-	}
+	return s.removeLine(cart, cartItem)
+}
 
-	if err := s.cartRepo.RemoveItem(cartItemID); err != nil {
+// removeLine removes a line from the cart together with its hold
+func (s *CartService) removeLine(cart *models.Cart, cartItem *models.CartItem) (*models.Cart, error) {
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		cartRepo := s.cartRepo.WithTx(tx)
+		if _, err := lockActiveCart(cartRepo, cart.ID); err != nil {
+			return err
+		}
+		if _, err := s.holdService.Set(tx, cart.ID, cartItem.ItemID, 0); err != nil {
+			return err
+		}
+		return cartRepo.RemoveItem(cartItem.ID)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -208,9 +348,9 @@ func (s *CartService) RemoveItem(userID, cartItemID uuid.UUID) (*models.Cart, er
 	return s.cartRepo.FindByID(cart.ID)
 }
 
-// ClearCart removes all items from the cart
-func (s *CartService) ClearCart(userID uuid.UUID) error {
-	cart, err := s.cartRepo.FindActiveByUserID(userID)
+// ClearCart removes all items from the user's cart at the selected pantry
+func (s *CartService) ClearCart(userID uuid.UUID, pantryID *uuid.UUID) error {
+	cart, err := s.GetCurrentCart(userID, pantryID)
 	if err != nil {
 		return err
 	}
@@ -218,66 +358,276 @@ func (s *CartService) ClearCart(userID uuid.UUID) error {
 		return errors.New("no active cart found")
 	}
 
-	return s.cartRepo.ClearCart(cart.ID)
+	return s.txManager.Transaction(func(tx *gorm.DB) error {
+		cartRepo := s.cartRepo.WithTx(tx)
+		if _, err := lockActiveCart(cartRepo, cart.ID); err != nil {
+			return err
+		}
+		if err := s.holdService.ReleaseCart(tx, cart.ID); err != nil {
+			return err
+		}
+		return cartRepo.ClearCart(cart.ID)
+	})
 }
 
-// Checkout converts the cart to an order
-func (s *CartService) Checkout(userID uuid.UUID, notes string) (*models.Order, error) {
-	// Get user's active cart
-	cart, err := s.cartRepo.FindActiveByUserID(userID)
+// Checkout converts the cart to an order. The cart's holds are released and
+// inventory is decremented, the cart is submitted, the pickup slot is booked
+// and the order is created in a single transaction, so a failure at any step
+// leaves stock untouched. If any item ran out, an *InsufficientStockError
+// naming every affected item is returned.
+func (s *CartService) Checkout(userID uuid.UUID, pantryID *uuid.UUID, req *CheckoutRequest) (*models.Order, error) {
+	// Get user's active cart at the selected pantry
+	cart, err := s.GetCurrentCart(userID, pantryID)
 	if err != nil {
 		return nil, err
 	}
 	if cart == nil {
 		return nil, errors.New("no active cart found")
 	}
+	if !cart.Pantry.IsActive {
+		return nil, errors.New("pantry is not active")
+	}
 
 	if len(cart.Items) == 0 {
 		return nil, errors.New("cart is empty")
 	}
 
-	// Verify all items are still available and reduce inventory
-	for _, cartItem := range cart.Items {
-		item, err := s.itemRepo.FindByID(cartItem.ItemID)
+	order := &models.Order{
+		ID:       uuid.New(), // Known up front so stock movements can refer to it
+		CartID:   cart.ID,
+		UserID:   &userID,
+		PantryID: cart.PantryID,
+		Notes:    req.Notes,
+	}
+	if err := applyFulfillment(order, &cart.Pantry, req); err != nil {
+		return nil, err
+	}
+
+	workflow, err := s.workflowRepo.FindForPantry(cart.PantryID, order.FulfillmentType)
+	if err != nil {
+		return nil, err
+	}
+	order.Status = workflow.InitialStatus
+
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		cartRepo := s.cartRepo.WithTx(tx)
+
+		// Lock the user so concurrent checkouts see each other's orders when
+		// counting visits
+		if err := s.userRepo.WithTx(tx).LockByID(userID); err != nil {
+			return err
+		}
+		if err := checkVisitLimits(s.visitLimitRepo.WithTx(tx), s.orderRepo.WithTx(tx), userID, &cart.Pantry); err != nil {
+			return err
+		}
+
+		// Claim the cart first so a second checkout of the same cart fails fast
+		submitted, err := cartRepo.MarkSubmitted(cart.ID)
 		if err != nil {
-			return nil, errors.New("item not found: " + cartItem.Item.Name)
+			return err
+		}
+		if !submitted {
+			return errors.New("cart has already been checked out")
 		}
 
-		if !item.IsAvailable {
-			return nil, errors.New("item no longer available: " + item.Name)
+		// Re-read the cart items now that the cart row is locked
+		lockedCart, err := cartRepo.FindByID(cart.ID)
+		if err != nil {
+			return err
+		}
+		if len(lockedCart.Items) == 0 {
+			return errors.New("cart is empty")
 		}
 
-		if item.Quantity < cartItem.Quantity {
-			return nil, errors.New("insufficient quantity for: " + item.Name)
+		// Limits may have been tightened since the items were added
+		if err := checkQuantityLimits(lockedCart.Items, lockedCart.User.HouseholdSize); err != nil {
+			return err
+		}
+		if err := cartRepo.SnapshotRequestedQuantities(cart.ID); err != nil {
+			return err
 		}
 
-		// Reduce inventory quantity
-		item.Quantity -= cartItem.Quantity
+		// Turn the cart's holds into a permanent decrement. Holds that already
+		// expired are simply gone, so those items are taken from unheld stock.
+		if err := s.holdService.ReleaseCart(tx, cart.ID); err != nil {
+			return err
+		}
+		ref := models.StockMovement{ActorID: &userID, OrderID: &order.ID}
+		if err := takeStock(tx, s.stockService, lockedCart.Items, ref); err != nil {
+			return err
+		}
 
-		// Mark as unavailable if out of stock
-		if item.Quantity == 0 {
-			item.IsAvailable = false
+		if req.PickupSlotID != nil {
+			if err := bookSlot(s.slotRepo.WithTx(tx), order, *req.PickupSlotID); err != nil {
+				return err
+			}
 		}
 
-		if err := s.itemRepo.Update(item); err != nil {
-			return nil, errors.New("failed to update inventory for: " + item.Name)
+		if order.FulfillmentType == models.FulfillmentPickup {
+			err = createWithPickupCode(tx, s.orderRepo, order)
+		} else {
+			err = s.orderRepo.WithTx(tx).Create(order)
+		}
+		if err != nil {
+			return err
+		}
+		err = s.eventRepo.WithTx(tx).Create(&models.OrderEvent{
+			OrderID:  order.ID,
+			Type:     models.OrderEventCreated,
+			ActorID:  &userID,
+			ToStatus: order.Status,
+		})
+		if err != nil {
+			return err
 		}
+		return s.staffService.AutoAssign(tx, order)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Create order
-	order := &models.Order{
-		CartID:   cart.ID,
-		UserID:   userID,
-		PantryID: cart.PantryID,
-		Status:   models.OrderStatusPending,
-		Notes:    notes,
+	// Reload order with associations
+	return s.orderRepo.FindByID(order.ID)
+}
+
+// lockActiveCart locks a cart against checkout for the rest of the transaction
+// and reloads it, failing if it is no longer active. Every change to a cart's
+// lines takes this lock first, so a checkout never misses a line added
+// concurrently.
+func lockActiveCart(cartRepo *repositories.CartRepository, cartID uuid.UUID) (*models.Cart, error) {
+	active, err := cartRepo.LockActive(cartID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, errCartNotActive
+	}
+	return cartRepo.FindByID(cartID)
+}
+
+// takeStock decrements inventory for the cart lines and records the
+// distributions in the stock ledger, referring to ref's order and actor. Items
+// are decremented in a stable order so concurrent checkouts lock item rows in
+// the same sequence and cannot deadlock. If any item ran out, an
+// *InsufficientStockError naming every affected item is returned.
+func takeStock(tx *gorm.DB, stockService *StockService, lines []models.CartItem, ref models.StockMovement) error {
+	sorted := make([]models.CartItem, len(lines))
+	copy(sorted, lines)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].ItemID[:], sorted[j].ItemID[:]) < 0
+	})
+
+	var outOfStock []string
+	for _, line := range sorted {
+		ok, err := stockService.Take(tx, line.ItemID, line.Quantity, ref)
+		if err != nil {
+			return errors.New("failed to update inventory for: " + line.Item.Name)
+		}
+		if !ok {
+			outOfStock = append(outOfStock, line.Item.Name)
+		}
+	}
+	if len(outOfStock) > 0 {
+		return &InsufficientStockError{Items: outOfStock}
+	}
+	return nil
+}
+
+// Reorder fills the user's active cart at the order's pantry with the items of
+// one of their previous orders, as originally requested. Items that are no
+// longer available are skipped and quantities are clamped to current stock
+// and per-order limits.
+func (s *CartService) Reorder(userID, orderID uuid.UUID) (*ReorderResult, error) {
+	order, err := s.orderRepo.FindByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !order.IsOwnedBy(userID) {
+		return nil, errors.New("unauthorized to reorder this order")
 	}
 
-	// Update cart status
-	cart.Status = models.CartStatusSubmitted
-	if err := s.cartRepo.Update(cart); err != nil {
+	if !order.Pantry.IsActive {
+		return nil, errors.New("pantry is not active")
+	}
+	cart, err := s.GetOrCreateCart(userID, order.PantryID)
+	if err != nil {
 		return nil, err
 	}
 
-	return order, nil
+	// Reorder what the client asked for rather than what staff substituted,
+	// merging lines that end up pointing at the same item
+	var itemIDs []uuid.UUID
+	requested := make(map[uuid.UUID]int)
+	for _, line := range order.Cart.Items {
+		itemID, quantity := line.ItemID, line.Quantity
+		if line.SubstitutedForID != nil {
+			itemID = *line.SubstitutedForID
+		}
+		if line.RequestedQuantity > 0 {
+			quantity = line.RequestedQuantity
+		}
+		if quantity <= 0 {
+			continue
+		}
+		if _, ok := requested[itemID]; !ok {
+			itemIDs = append(itemIDs, itemID)
+		}
+		requested[itemID] += quantity
+	}
+
+	result := &ReorderResult{
+		Added:   []ReorderLine{},
+		Reduced: []ReorderLine{},
+		Skipped: []ReorderLine{},
+	}
+	for _, itemID := range itemIDs {
+		line := ReorderLine{ItemID: itemID, Requested: requested[itemID]}
+
+		item, err := s.itemRepo.FindByID(itemID)
+		if err != nil {
+			line.Reason = "item no longer exists"
+			result.Skipped = append(result.Skipped, line)
+			continue
+		}
+		line.Name = item.Name
+		if !item.IsAvailable || item.PantryID != order.PantryID {
+			line.Reason = "item is not available"
+			result.Skipped = append(result.Skipped, line)
+			continue
+		}
+
+		// Available stock already excludes what this cart holds
+		quantity := line.Requested
+		if stock := item.AvailableQuantity; quantity > stock {
+			quantity = stock
+			line.Reason = "limited by available stock"
+		}
+		if allowance := remainingAllowance(cart.Items, item, cart.User.HouseholdSize); allowance >= 0 && quantity > allowance {
+			quantity = allowance
+			line.Reason = "limited by order limits"
+		}
+		if quantity <= 0 {
+			result.Skipped = append(result.Skipped, line)
+			continue
+		}
+
+		updated, err := s.AddItem(userID, &AddItemRequest{ItemID: item.ID, Quantity: quantity})
+		if err != nil {
+			// Stock may have moved since it was read
+			line.Reason = err.Error()
+			result.Skipped = append(result.Skipped, line)
+			continue
+		}
+		cart = updated
+
+		line.Added = quantity
+		if quantity < line.Requested {
+			result.Reduced = append(result.Reduced, line)
+		} else {
+			result.Added = append(result.Added, line)
+		}
+	}
+
+	result.Cart = cart
+	return result, nil
 }
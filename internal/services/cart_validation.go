@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CartProblemCode identifies why a cart line cannot be checked out as is
+type CartProblemCode string
+
+const (
+	CartProblemUnavailable       CartProblemCode = "unavailable"
+	CartProblemInsufficientStock CartProblemCode = "insufficient_stock"
+	CartProblemOverLimit         CartProblemCode = "over_limit"
+)
+
+// CartLineProblem describes a cart line that would fail checkout and the
+// quantity that would fix it; a suggested quantity of 0 means removing the line
+type CartLineProblem struct {
+	CartItemID        uuid.UUID       `json:"cart_item_id"`
+	ItemID            uuid.UUID       `json:"item_id"`
+	Name              string          `json:"name"`
+	Quantity          int             `json:"quantity"`
+	Code              CartProblemCode `json:"code"`
+	Message           string          `json:"message"`
+	SuggestedQuantity int             `json:"suggested_quantity"`
+	Fixed             bool            `json:"fixed"`
+}
+
+// CartValidation is the result of checking a cart before checkout
+type CartValidation struct {
+	Valid        bool              `json:"valid"`
+	PantryActive bool              `json:"pantry_active"`
+	Problems     []CartLineProblem `json:"problems"`
+	Cart         *models.Cart      `json:"cart"`
+}
+
+// ValidateCart checks every line of the user's cart at the selected pantry
+// against current availability, stock and per-order limits, and reports the
+// pantry's status. With fix set, lines are clamped to their suggested
+// quantity or removed; lines whose stock moved in the meantime stay unfixed.
+func (s *CartService) ValidateCart(userID uuid.UUID, pantryID *uuid.UUID, fix bool) (*CartValidation, error) {
+	cart, err := s.GetCurrentCart(userID, pantryID)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
+		return nil, errors.New("no active cart found")
+	}
+
+	held, err := s.holdService.CartHolds(cart.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CartValidation{
+		PantryActive: cart.Pantry.IsActive,
+		Problems:     []CartLineProblem{},
+		Cart:         cart,
+	}
+
+	// Lines are checked in cart order; each kept line counts against the
+	// limits of the lines after it
+	var kept []models.CartItem
+	for _, line := range cart.Items {
+		item := line.Item
+		problem := CartLineProblem{
+			CartItemID: line.ID,
+			ItemID:     line.ItemID,
+			Name:       item.Name,
+			Quantity:   line.Quantity,
+		}
+
+		quantity := line.Quantity
+		if !item.IsAvailable || item.PantryID != cart.PantryID {
+			quantity = 0
+			problem.Code = CartProblemUnavailable
+			problem.Message = "item is no longer available"
+		} else if stock := item.AvailableQuantity + held[item.ID]; quantity > stock {
+			quantity = stock
+			problem.Code = CartProblemInsufficientStock
+			problem.Message = fmt.Sprintf("only %d available", stock)
+		}
+		if quantity > 0 {
+			if allowance := remainingAllowance(kept, &item, cart.User.HouseholdSize); allowance >= 0 && quantity > allowance {
+				quantity = allowance
+				problem.Code = CartProblemOverLimit
+				problem.Message = fmt.Sprintf("at most %d more fit within the order limits", allowance)
+			}
+		}
+
+		if problem.Code != "" {
+			problem.SuggestedQuantity = quantity
+			result.Problems = append(result.Problems, problem)
+		}
+		if quantity > 0 {
+			line.Quantity = quantity
+			kept = append(kept, line)
+		}
+	}
+
+	if fix && len(result.Problems) > 0 {
+		lines := make(map[uuid.UUID]models.CartItem, len(cart.Items))
+		for _, line := range cart.Items {
+			lines[line.ID] = line
+		}
+
+		err := s.txManager.Transaction(func(tx *gorm.DB) error {
+			cartRepo := s.cartRepo.WithTx(tx)
+			if _, err := lockActiveCart(cartRepo, cart.ID); err != nil {
+				return err
+			}
+			for i := range result.Problems {
+				problem := &result.Problems[i]
+				ok, err := s.holdService.Set(tx, cart.ID, problem.ItemID, problem.SuggestedQuantity)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+				if problem.SuggestedQuantity == 0 {
+					err = cartRepo.RemoveItem(problem.CartItemID)
+				} else {
+					line := lines[problem.CartItemID]
+					line.Quantity = problem.SuggestedQuantity
+					err = cartRepo.UpdateItem(&line)
+				}
+				if err != nil {
+					return err
+				}
+				problem.Fixed = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Cart, err = s.cartRepo.FindByID(cart.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Valid = result.PantryActive
+	for _, problem := range result.Problems {
+		if !problem.Fixed {
+			result.Valid = false
+		}
+	}
+	return result, nil
+}
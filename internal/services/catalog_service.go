@@ -0,0 +1,145 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/byte4bite/byte4bite/internal/barcode"
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+)
+
+// CatalogService maintains the product catalog used to prefill scanned items
+type CatalogService struct {
+	catalogRepo *repositories.CatalogRepository
+}
+
+// NewCatalogService creates a new catalog service
+func NewCatalogService(catalogRepo *repositories.CatalogRepository) *CatalogService {
+	return &CatalogService{
+		catalogRepo: catalogRepo,
+	}
+}
+
+// CatalogImportResult summarizes a catalog import
+type CatalogImportResult struct {
+	Imported int      `json:"imported"`
+	Skipped  int      `json:"skipped"`
+	Errors   []string `json:"errors"` // The first maxImportErrors problems, by line
+	Total    int64    `json:"total"`  // Products in the catalog after the import
+}
+
+// maxImportErrors bounds how many row errors an import reports
+const maxImportErrors = 50
+
+// catalogColumns are the CSV columns a catalog file may have; barcode and name are required
+var catalogColumns = []string{"barcode", "name", "description", "brand", "unit", "category", "image_url"}
+
+// ImportCSV adds the products of a CSV catalog file with a header row to the
+// catalog, replacing products with the same barcode. Rows with an invalid
+// barcode or no name are skipped; a barcode listed twice keeps its last row.
+func (s *CatalogService) ImportCSV(r io.Reader) (*CatalogImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("catalog file is empty or not valid CSV")
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for _, known := range catalogColumns {
+			if name == known {
+				columns[name] = i
+			}
+		}
+	}
+	if _, ok := columns["barcode"]; !ok {
+		return nil, errors.New("catalog file must have a barcode column")
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("catalog file must have a name column")
+	}
+
+	result := &CatalogImportResult{Errors: []string{}}
+	skip := func(line int, problem string) {
+		result.Skipped++
+		if len(result.Errors) < maxImportErrors {
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %s", line, problem))
+		}
+	}
+
+	products := make(map[string]models.CatalogProduct)
+	var order []string
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		code, err := barcode.Normalize(field("barcode"))
+		if err != nil {
+			skip(line, err.Error())
+			continue
+		}
+		product := models.CatalogProduct{
+			Barcode:      code,
+			Name:         field("name"),
+			Description:  field("description"),
+			Brand:        field("brand"),
+			Unit:         field("unit"),
+			CategoryName: field("category"),
+			ImageURL:     field("image_url"),
+		}
+		if product.Name == "" {
+			skip(line, "name is required")
+			continue
+		}
+
+		if _, seen := products[code]; !seen {
+			order = append(order, code)
+		}
+		products[code] = product
+	}
+
+	batch := make([]models.CatalogProduct, 0, len(order))
+	for _, code := range order {
+		batch = append(batch, products[code])
+	}
+	if err := s.catalogRepo.Upsert(batch); err != nil {
+		return nil, err
+	}
+	result.Imported = len(batch)
+
+	total, err := s.catalogRepo.Count()
+	if err != nil {
+		return nil, err
+	}
+	result.Total = total
+
+	return result, nil
+}
+
+// GetProduct looks up a catalog product by barcode
+func (s *CatalogService) GetProduct(code string) (*models.CatalogProduct, error) {
+	code, err := barcode.Normalize(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.catalogRepo.FindByBarcode(code)
+}
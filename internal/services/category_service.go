@@ -23,12 +23,18 @@ type CreateCategoryRequest struct {
 	Name        string     `json:"name" binding:"required"`
 	Description string     `json:"description"`
 	PantryID    uuid.UUID  `json:"pantry_id" binding:"required"`
+
+	MaxPerOrder           int  `json:"max_per_order" binding:"min=0"`
+	ScaleLimitByHousehold bool `json:"scale_limit_by_household"`
 }
 
 // UpdateCategoryRequest represents a category update request
 type UpdateCategoryRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+
+	MaxPerOrder           *int  `json:"max_per_order" binding:"omitempty,min=0"`
+	ScaleLimitByHousehold *bool `json:"scale_limit_by_household"`
 }
 
 // CreateCategory creates a new category
@@ -37,6 +43,9 @@ func (s *CategoryService) CreateCategory(req *CreateCategoryRequest) (*models.Ca
 		Name:        req.Name,
 		Description: req.Description,
 		PantryID:    req.PantryID,
+
+		MaxPerOrder:           req.MaxPerOrder,
+		ScaleLimitByHousehold: req.ScaleLimitByHousehold,
 	}
 
 	if err := s.categoryRepo.Create(category); err != nil {
@@ -69,6 +78,12 @@ func (s *CategoryService) UpdateCategory(id uuid.UUID, req *UpdateCategoryReques
 	if req.Description != "" {
 		category.Description = req.Description
 	}
+	if req.MaxPerOrder != nil {
+		category.MaxPerOrder = *req.MaxPerOrder
+	}
+	if req.ScaleLimitByHousehold != nil {
+		category.ScaleLimitByHousehold = *req.ScaleLimitByHousehold
+	}
 
 	if err := s.categoryRepo.Update(category); err != nil {
 		return nil, err
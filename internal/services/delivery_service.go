@@ -0,0 +1,261 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeliveryService handles business logic for home delivery orders
+type DeliveryService struct {
+	orderRepo    *repositories.OrderRepository
+	userRepo     *repositories.UserRepository
+	pantryRepo   *repositories.PantryRepository
+	eventRepo    *repositories.OrderEventRepository
+	workflowRepo *repositories.WorkflowRepository
+	txManager    *repositories.TxManager
+	staffService *StaffService
+}
+
+// NewDeliveryService creates a new delivery service
+func NewDeliveryService(orderRepo *repositories.OrderRepository, userRepo *repositories.UserRepository, pantryRepo *repositories.PantryRepository, eventRepo *repositories.OrderEventRepository, workflowRepo *repositories.WorkflowRepository, txManager *repositories.TxManager, staffService *StaffService) *DeliveryService {
+	return &DeliveryService{
+		orderRepo:    orderRepo,
+		userRepo:     userRepo,
+		pantryRepo:   pantryRepo,
+		eventRepo:    eventRepo,
+		workflowRepo: workflowRepo,
+		txManager:    txManager,
+		staffService: staffService,
+	}
+}
+
+// DeliveryDetails holds the delivery address and window chosen at checkout
+type DeliveryDetails struct {
+	Address      string    `json:"address" binding:"required"`
+	City         string    `json:"city" binding:"required"`
+	State        string    `json:"state"`
+	ZipCode      string    `json:"zip_code" binding:"required"`
+	WindowStart  time.Time `json:"window_start" binding:"required"`
+	WindowEnd    time.Time `json:"window_end" binding:"required"`
+	Instructions string    `json:"instructions"`
+}
+
+// AssignDriverRequest represents a request to assign a driver to a delivery order
+type AssignDriverRequest struct {
+	DriverID uuid.UUID `json:"driver_id" binding:"required"`
+}
+
+// RunSheetStop is one delivery on a driver's run
+type RunSheetStop struct {
+	OrderID      uuid.UUID          `json:"order_id"`
+	Status       models.OrderStatus `json:"status"`
+	ClientName   string             `json:"client_name"`
+	ClientPhone  string             `json:"client_phone"`
+	Address      string             `json:"address"`
+	City         string             `json:"city"`
+	State        string             `json:"state"`
+	ZipCode      string             `json:"zip_code"`
+	WindowStart  *time.Time         `json:"window_start"`
+	WindowEnd    *time.Time         `json:"window_end"`
+	Instructions string             `json:"instructions"`
+	ItemCount    int                `json:"item_count"`
+}
+
+// DriverRun groups the stops of one driver on one day
+type DriverRun struct {
+	Driver *models.User   `json:"driver"` // nil for orders without a driver yet
+	Date   string         `json:"date"`
+	Stops  []RunSheetStop `json:"stops"`
+}
+
+// AssignDriver assigns a driver, who must be staff at the order's pantry, to
+// an open delivery order
+func (s *DeliveryService) AssignDriver(orderID, actorID, driverID uuid.UUID) error {
+	return s.txManager.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(s.orderRepo.WithTx(tx), orderID)
+		if err != nil {
+			return err
+		}
+		if order.FulfillmentType != models.FulfillmentDelivery {
+			return errors.New("drivers can only be assigned to delivery orders")
+		}
+
+		workflow, err := s.workflowRepo.FindForPantry(order.PantryID, order.FulfillmentType)
+		if err != nil {
+			return err
+		}
+		if workflow.IsFinal(order.Status) {
+			return errors.New("cannot assign a driver to cancelled or completed orders")
+		}
+		if err := s.staffService.ValidateStaff(driverID, order.PantryID); err != nil {
+			return err
+		}
+
+		order.DriverID = &driverID
+		if err := s.orderRepo.WithTx(tx).Update(order); err != nil {
+			return err
+		}
+		return s.eventRepo.WithTx(tx).Create(&models.OrderEvent{
+			OrderID:      order.ID,
+			Type:         models.OrderEventDriver,
+			ActorID:      &actorID,
+			FromStatus:   order.Status,
+			ToStatus:     order.Status,
+			AssignedToID: &driverID,
+		})
+	})
+}
+
+// GetRunSheet groups a pantry's delivery orders in the given status (ready by
+// default) by driver and delivery day, with stops sorted by zip code. When date
+// (YYYY-MM-DD, pantry time) is set, only that day's deliveries are included.
+func (s *DeliveryService) GetRunSheet(pantryID uuid.UUID, date string, status models.OrderStatus) ([]DriverRun, error) {
+	pantry, err := s.pantryRepo.FindByID(pantryID)
+	if err != nil {
+		return nil, err
+	}
+	loc := pantry.Location()
+
+	if status == "" {
+		status = models.OrderStatusReady
+	}
+
+	var from, to *time.Time
+	if date != "" {
+		day, err := time.ParseInLocation("2006-01-02", date, loc)
+		if err != nil {
+			return nil, errors.New("date must be formatted as YYYY-MM-DD")
+		}
+		next := day.AddDate(0, 0, 1)
+		from, to = &day, &next
+	}
+
+	orders, err := s.orderRepo.FindDeliveries(pantryID, status, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type runKey struct {
+		driverID uuid.UUID
+		date     string
+	}
+	runs := make(map[runKey]*DriverRun)
+	var keys []runKey
+
+	for _, order := range orders {
+		key := runKey{date: "unscheduled"}
+		if order.DriverID != nil {
+			key.driverID = *order.DriverID
+		}
+		if order.DeliveryWindowStart != nil {
+			key.date = order.DeliveryWindowStart.In(loc).Format("2006-01-02")
+		}
+
+		run, ok := runs[key]
+		if !ok {
+			run = &DriverRun{Driver: order.Driver, Date: key.date}
+			runs[key] = run
+			keys = append(keys, key)
+		}
+
+		itemCount := 0
+		for _, cartItem := range order.Cart.Items {
+			itemCount += cartItem.Quantity
+		}
+
+		run.Stops = append(run.Stops, RunSheetStop{
+			OrderID:      order.ID,
+			Status:       order.Status,
+			ClientName:   order.ClientName(),
+			ClientPhone:  order.ClientPhone(),
+			Address:      order.DeliveryAddress,
+			City:         order.DeliveryCity,
+			State:        order.DeliveryState,
+			ZipCode:      order.DeliveryZipCode,
+			WindowStart:  order.DeliveryWindowStart,
+			WindowEnd:    order.DeliveryWindowEnd,
+			Instructions: order.DeliveryInstructions,
+			ItemCount:    itemCount,
+		})
+	}
+
+	// Order runs by day, then driver, with unassigned orders last
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].date != keys[j].date {
+			return keys[i].date < keys[j].date
+		}
+		if (keys[i].driverID == uuid.Nil) != (keys[j].driverID == uuid.Nil) {
+			return keys[j].driverID == uuid.Nil
+		}
+		return runs[keys[i]].driverName() < runs[keys[j]].driverName()
+	})
+
+	result := make([]DriverRun, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *runs[key])
+	}
+	return result, nil
+}
+
+// driverName returns the display name of the run's driver
+func (r *DriverRun) driverName() string {
+	if r.Driver == nil {
+		return ""
+	}
+	return r.Driver.LastName + " " + r.Driver.FirstName
+}
+
+// applyFulfillment validates the fulfillment choice of a checkout request and
+// copies the delivery details onto the order
+func applyFulfillment(order *models.Order, pantry *models.Pantry, req *CheckoutRequest) error {
+	fulfillment := req.FulfillmentType
+	if fulfillment == "" {
+		fulfillment = models.FulfillmentPickup
+	}
+	order.FulfillmentType = fulfillment
+
+	switch fulfillment {
+	case models.FulfillmentPickup:
+		if req.PickupSlotID == nil && pantry.SlotRequired {
+			return errors.New("a pickup slot is required for this pantry")
+		}
+		return nil
+
+	case models.FulfillmentDelivery:
+		if !pantry.Delivers {
+			return errors.New("this pantry does not offer delivery")
+		}
+		if req.PickupSlotID != nil {
+			return errors.New("pickup slots cannot be booked for delivery orders")
+		}
+		if req.Delivery == nil {
+			return errors.New("delivery details are required for delivery orders")
+		}
+
+		delivery := req.Delivery
+		if !delivery.WindowEnd.After(delivery.WindowStart) {
+			return errors.New("delivery window must end after it starts")
+		}
+		if delivery.WindowStart.Before(time.Now()) {
+			return errors.New("delivery window must be in the future")
+		}
+
+		order.DeliveryAddress = delivery.Address
+		order.DeliveryCity = delivery.City
+		order.DeliveryState = delivery.State
+		order.DeliveryZipCode = delivery.ZipCode
+		order.DeliveryWindowStart = &delivery.WindowStart
+		order.DeliveryWindowEnd = &delivery.WindowEnd
+		order.DeliveryInstructions = delivery.Instructions
+		return nil
+
+	default:
+		return errors.New("fulfillment_type must be pickup or delivery")
+	}
+}
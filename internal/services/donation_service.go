@@ -1,29 +1,42 @@
 package services
 
 import (
+	"bytes"
 	"errors"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/byte4bite/byte4bite/internal/repositories"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // DonationService handles business logic for donations
 type DonationService struct {
 	donationRepo *repositories.DonationRepository
 	pantryRepo   *repositories.PantryRepository
+	itemRepo     *repositories.ItemRepository
+	categoryRepo *repositories.CategoryRepository
+	stockService *StockService
+	txManager    *repositories.TxManager
 }
 
 // NewDonationService creates a new donation service
-func NewDonationService(donationRepo *repositories.DonationRepository, pantryRepo *repositories.PantryRepository) *DonationService {
+func NewDonationService(donationRepo *repositories.DonationRepository, pantryRepo *repositories.PantryRepository, itemRepo *repositories.ItemRepository, categoryRepo *repositories.CategoryRepository, stockService *StockService, txManager *repositories.TxManager) *DonationService {
 	return &DonationService{
 		donationRepo: donationRepo,
 		pantryRepo:   pantryRepo,
+		itemRepo:     itemRepo,
+		categoryRepo: categoryRepo,
+		stockService: stockService,
+		txManager:    txManager,
 	}
 }
 
-// CreateDonationRequest represents a request to create a donation
+// CreateDonationRequest represents a request to create a donation. Items of
+// in-kind donations are recorded by staff afterwards.
 type CreateDonationRequest struct {
 	PantryID     uuid.UUID `json:"pantry_id" binding:"required"`
 	DonorName    string    `json:"donor_name" binding:"required"`
@@ -34,6 +47,23 @@ type CreateDonationRequest struct {
 	DonationDate time.Time `json:"donation_date"`
 }
 
+// DonationItemRequest describes one line of an in-kind donation. It names an
+// existing item of the pantry, or gives the name, category and unit of a new
+// item to create when the donation is received.
+type DonationItemRequest struct {
+	ItemID     *uuid.UUID `json:"item_id"`
+	Name       string     `json:"name"`
+	CategoryID *uuid.UUID `json:"category_id"`
+	Quantity   int        `json:"quantity" binding:"required,min=1"`
+	Unit       string     `json:"unit"` // Defaults to the item's unit
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// SetDonationItemsRequest replaces the items of a donation not yet received
+type SetDonationItemsRequest struct {
+	Items []DonationItemRequest `json:"items" binding:"dive"`
+}
+
 // UpdateDonationRequest represents a request to update a donation
 type UpdateDonationRequest struct {
 	DonorName    *string    `json:"donor_name"`
@@ -205,14 +235,170 @@ func (s *DonationService) UpdateDonation(id uuid.UUID, req *UpdateDonationReques
 // DeleteDonation deletes a donation
 func (s *DonationService) DeleteDonation(id uuid.UUID) error {
 	// Check if donation exists
-	_, err := s.donationRepo.FindByID(id)
+	donation, err := s.donationRepo.FindByID(id)
 	if err != nil {
 		return err
 	}
+	// Received stock refers back to the donation
+	if donation.IsReceived() {
+		return errors.New("received donations cannot be deleted")
+	}
 
 	return s.donationRepo.Delete(id)
 }
 
+// SetItems replaces the items of a donation that has not been received yet
+func (s *DonationService) SetItems(id uuid.UUID, req *SetDonationItemsRequest) (*models.Donation, error) {
+	donation, err := s.donationRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.buildItems(donation.PantryID, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		donationRepo := s.donationRepo.WithTx(tx)
+		locked, err := donationRepo.FindForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if locked.IsReceived() {
+			return errors.New("donation was already received")
+		}
+		return donationRepo.ReplaceItems(id, items)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.donationRepo.FindByID(id)
+}
+
+// ReceiveDonation adds a donation's items to its pantry's stock in one
+// transaction. Each line becomes a lot of its item, creating the item first
+// for lines that describe a new one, and the lot is recorded on the line.
+func (s *DonationService) ReceiveDonation(actorID, id uuid.UUID) (*models.Donation, error) {
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		donationRepo := s.donationRepo.WithTx(tx)
+		itemRepo := s.itemRepo.WithTx(tx)
+
+		donation, err := donationRepo.FindForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if donation.IsReceived() {
+			return errors.New("donation was already received")
+		}
+		if len(donation.Items) == 0 {
+			return errors.New("donation has no items to receive")
+		}
+
+		// Existing items are stocked in a stable order so concurrent receipts
+		// cannot deadlock; new items come last
+		lines := donation.Items
+		sort.SliceStable(lines, func(i, j int) bool {
+			if lines[i].ItemID == nil || lines[j].ItemID == nil {
+				return lines[i].ItemID != nil && lines[j].ItemID == nil
+			}
+			return bytes.Compare(lines[i].ItemID[:], lines[j].ItemID[:]) < 0
+		})
+
+		for i := range lines {
+			line := &lines[i]
+			if line.ItemID == nil {
+				item := &models.Item{
+					Name:        line.Name,
+					CategoryID:  *line.CategoryID,
+					PantryID:    donation.PantryID,
+					Unit:        line.Unit,
+					IsAvailable: true,
+				}
+				if err := itemRepo.Create(item); err != nil {
+					return err
+				}
+				line.ItemID = &item.ID
+			} else {
+				item, err := itemRepo.FindByID(*line.ItemID)
+				if err != nil {
+					return err
+				}
+				if item.PantryID != donation.PantryID {
+					return errors.New("item does not belong to the donation's pantry: " + item.Name)
+				}
+			}
+
+			lot, err := s.stockService.Receive(tx, *line.ItemID, line.Quantity, LotDetails{
+				ExpiresAt:  line.ExpiresAt,
+				Source:     "donation",
+				DonationID: &donation.ID,
+			}, models.StockMovement{
+				ActorID: &actorID,
+				Reason:  "donation from " + donation.DonorName,
+			})
+			if err != nil {
+				return err
+			}
+			line.LotID = &lot.ID
+			if err := donationRepo.UpdateItem(line); err != nil {
+				return err
+			}
+		}
+
+		return donationRepo.MarkReceived(donation.ID, actorID, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.donationRepo.FindByID(id)
+}
+
+// buildItems validates donation item requests against a pantry
+func (s *DonationService) buildItems(pantryID uuid.UUID, reqs []DonationItemRequest) ([]models.DonationItem, error) {
+	var items []models.DonationItem
+	for _, req := range reqs {
+		if req.Quantity <= 0 {
+			return nil, errors.New("donation item quantity must be positive")
+		}
+		line := models.DonationItem{
+			Quantity:  req.Quantity,
+			Unit:      strings.TrimSpace(req.Unit),
+			ExpiresAt: req.ExpiresAt,
+		}
+
+		if req.ItemID != nil {
+			// Items of other pantries are reported as missing rather than named
+			item, err := s.itemRepo.FindByID(*req.ItemID)
+			if err != nil || item.PantryID != pantryID {
+				return nil, errors.New("item not found")
+			}
+			if line.Unit == "" {
+				line.Unit = item.Unit
+			} else if !strings.EqualFold(line.Unit, item.Unit) {
+				return nil, errors.New("unit of " + item.Name + " must be " + item.Unit)
+			}
+			line.ItemID = &item.ID
+			line.Name = item.Name
+		} else {
+			line.Name = strings.TrimSpace(req.Name)
+			if line.Name == "" || req.CategoryID == nil || line.Unit == "" {
+				return nil, errors.New("new donation items require a name, category_id and unit")
+			}
+			category, err := s.categoryRepo.FindByID(*req.CategoryID)
+			if err != nil || category.PantryID != pantryID {
+				return nil, errors.New("category not found")
+			}
+			line.CategoryID = &category.ID
+		}
+
+		items = append(items, line)
+	}
+	return items, nil
+}
+
 // MarkReceiptSent marks a donation receipt as sent
 func (s *DonationService) MarkReceiptSent(id uuid.UUID) (*models.Donation, error) {
 	if err := s.donationRepo.MarkReceiptSent(id); err != nil {
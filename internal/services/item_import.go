@@ -0,0 +1,435 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/xlsx"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Spreadsheet formats accepted by item imports and exports
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// maxImportRows bounds the number of items one import can create
+const maxImportRows = 5000
+
+// maxImportColumns bounds the columns read from a spreadsheet; columns the
+// import does not know are ignored but would still be held in memory
+const maxImportColumns = 100
+
+// exportPageSize is how many items an export loads at a time
+const exportPageSize = 500
+
+// ImportItemsRequest represents a spreadsheet of items to create in a pantry
+type ImportItemsRequest struct {
+	PantryID uuid.UUID
+	Format   string // csv or xlsx
+	DryRun   bool   // Validate only, creating nothing
+}
+
+// ImportRowError is a problem with one row of an import. Row is the
+// spreadsheet row number, counting the header as row 1.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportItemsResult reports an import. Nothing is created when any row has
+// an error or in a dry run; Created then counts the items that would be.
+type ImportItemsResult struct {
+	DryRun            bool             `json:"dry_run"`
+	Rows              int              `json:"rows"`
+	Created           int              `json:"created"`
+	CategoriesCreated []string         `json:"categories_created"`
+	Errors            []ImportRowError `json:"errors"`
+}
+
+// importRow is a validated import row
+type importRow struct {
+	line     int
+	req      CreateItemRequest
+	category string // Name of a category the import creates, if CategoryID is unset
+	barcode  *string
+}
+
+// Columns of an item spreadsheet. Imports require name, category and unit and
+// ignore columns they do not know, so an export can be edited and imported
+// into another pantry.
+var itemExportColumns = []string{
+	"id", "name", "description", "category", "unit", "barcode",
+	"quantity", "available_quantity", "held_quantity", "expired_quantity",
+	"low_stock_threshold", "max_per_order", "scale_limit_by_household", "is_available", "image_url",
+}
+
+var itemImportColumns = map[string]bool{
+	"name": true, "description": true, "category": true, "unit": true, "barcode": true,
+	"quantity": true, "low_stock_threshold": true, "max_per_order": true,
+	"scale_limit_by_household": true, "is_available": true, "image_url": true, "expires_at": true,
+}
+
+// ImportItems creates items from a CSV or XLSX spreadsheet with a header row.
+// Categories are matched by name within the pantry and created when missing.
+// All rows are validated first and the items are created in one transaction,
+// so an import either creates every item or none.
+func (s *ItemService) ImportItems(actorID uuid.UUID, req *ImportItemsRequest, file io.ReaderAt, size int64) (*ImportItemsResult, error) {
+	records, err := readSpreadsheet(req.Format, file, size)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		name = strings.TrimPrefix(name, "\ufeff")
+		name = strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(name)))
+		if itemImportColumns[name] {
+			columns[name] = i
+		}
+	}
+	for _, required := range []string{"name", "category", "unit"} {
+		if _, ok := columns[required]; !ok {
+			return nil, errors.New("file must have a " + required + " column")
+		}
+	}
+
+	categories, err := s.categoryRepo.FindByPantryID(req.PantryID)
+	if err != nil {
+		return nil, err
+	}
+	categoryIDs := make(map[string]uuid.UUID)
+	for _, category := range categories {
+		categoryIDs[strings.ToLower(category.Name)] = category.ID
+	}
+
+	result := &ImportItemsResult{DryRun: req.DryRun, CategoriesCreated: []string{}, Errors: []ImportRowError{}}
+	newCategories := make(map[string]bool)
+	barcodeRows := make(map[string]int)
+	var rows []importRow
+
+	for i, record := range records[1:] {
+		line := i + 2
+		field := func(name string) string {
+			j, ok := columns[name]
+			if !ok || j >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[j])
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		result.Rows++
+		if result.Rows > maxImportRows {
+			return nil, fmt.Errorf("imports are limited to %d items", maxImportRows)
+		}
+
+		row, rowErrors := s.parseImportRow(req.PantryID, line, field)
+		if row != nil && row.barcode != nil {
+			if first, ok := barcodeRows[*row.barcode]; ok {
+				rowErrors = append(rowErrors, ImportRowError{Row: line, Column: "barcode", Message: fmt.Sprintf("same barcode as row %d", first)})
+			} else {
+				barcodeRows[*row.barcode] = line
+			}
+		}
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+
+		key := strings.ToLower(row.category)
+		if id, ok := categoryIDs[key]; ok {
+			row.req.CategoryID = id
+		} else if !newCategories[key] {
+			newCategories[key] = true
+			result.CategoriesCreated = append(result.CategoriesCreated, row.category)
+		}
+		rows = append(rows, *row)
+	}
+
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+	result.Created = len(rows)
+	if req.DryRun {
+		return result, nil
+	}
+
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		categoryRepo := s.categoryRepo.WithTx(tx)
+		for _, name := range result.CategoriesCreated {
+			category := &models.Category{Name: name, PantryID: req.PantryID}
+			if err := categoryRepo.Create(category); err != nil {
+				return err
+			}
+			categoryIDs[strings.ToLower(name)] = category.ID
+		}
+
+		for i := range rows {
+			row := &rows[i]
+			if row.req.CategoryID == uuid.Nil {
+				row.req.CategoryID = categoryIDs[strings.ToLower(row.category)]
+			}
+			if _, err := s.createItem(tx, actorID, &row.req, row.barcode); err != nil {
+				return fmt.Errorf("row %d: %w", row.line, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// parseImportRow validates one import row; field returns a column's trimmed value
+func (s *ItemService) parseImportRow(pantryID uuid.UUID, line int, field func(string) string) (*importRow, []ImportRowError) {
+	var rowErrors []ImportRowError
+	fail := func(column, message string) {
+		rowErrors = append(rowErrors, ImportRowError{Row: line, Column: column, Message: message})
+	}
+
+	row := &importRow{
+		line: line,
+		req: CreateItemRequest{
+			PantryID:          pantryID,
+			Name:              field("name"),
+			Description:       field("description"),
+			Unit:              field("unit"),
+			ImageURL:          field("image_url"),
+			LowStockThreshold: 10,
+			IsAvailable:       true,
+		},
+		category: field("category"),
+	}
+	if row.req.Name == "" {
+		fail("name", "name is required")
+	}
+	if row.category == "" {
+		fail("category", "category is required")
+	}
+	if row.req.Unit == "" {
+		fail("unit", "unit is required")
+	}
+
+	counts := []struct {
+		column string
+		target *int
+	}{
+		{"quantity", &row.req.Quantity},
+		{"low_stock_threshold", &row.req.LowStockThreshold},
+		{"max_per_order", &row.req.MaxPerOrder},
+	}
+	for _, count := range counts {
+		value := field(count.column)
+		if value == "" {
+			continue
+		}
+		n, err := parseCount(value)
+		if err != nil {
+			fail(count.column, err.Error())
+			continue
+		}
+		*count.target = n
+	}
+
+	flags := []struct {
+		column string
+		target *bool
+	}{
+		{"is_available", &row.req.IsAvailable},
+		{"scale_limit_by_household", &row.req.ScaleLimitByHousehold},
+	}
+	for _, flag := range flags {
+		value := field(flag.column)
+		if value == "" {
+			continue
+		}
+		b, err := parseYesNo(value)
+		if err != nil {
+			fail(flag.column, err.Error())
+			continue
+		}
+		*flag.target = b
+	}
+
+	if value := field("expires_at"); value != "" {
+		expiresAt, err := parseDate(value)
+		if err != nil {
+			fail("expires_at", err.Error())
+		} else {
+			row.req.ExpiresAt = &expiresAt
+		}
+	}
+
+	if value := field("barcode"); value != "" {
+		// Spreadsheets may show long numbers in scientific notation
+		if strings.ContainsAny(value, "eE") {
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				value = strconv.FormatFloat(f, 'f', 0, 64)
+			}
+		}
+		code, err := s.checkBarcode(pantryID, uuid.Nil, value)
+		if err != nil {
+			fail("barcode", err.Error())
+		} else {
+			row.req.Barcode = *code
+			row.barcode = code
+		}
+	}
+
+	if len(rowErrors) > 0 {
+		return nil, rowErrors
+	}
+	return row, nil
+}
+
+// ExportItems writes a pantry's items with their categories and stock levels
+// to w as a CSV or XLSX spreadsheet, loading them a page at a time
+func (s *ItemService) ExportItems(pantryID uuid.UUID, format string, w io.Writer) error {
+	var writeRow func(values ...interface{}) error
+	var finish func() error
+
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		writeRow = func(values ...interface{}) error {
+			record := make([]string, len(values))
+			for i, value := range values {
+				record[i] = fmt.Sprint(value)
+			}
+			return cw.Write(record)
+		}
+		finish = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case FormatXLSX:
+		xw, err := xlsx.NewWriter(w, "Items")
+		if err != nil {
+			return err
+		}
+		writeRow = xw.WriteRow
+		finish = xw.Close
+	default:
+		return errors.New("format must be csv or xlsx")
+	}
+
+	header := make([]interface{}, len(itemExportColumns))
+	for i, column := range itemExportColumns {
+		header[i] = column
+	}
+	if err := writeRow(header...); err != nil {
+		return err
+	}
+
+	for offset := 0; ; offset += exportPageSize {
+		items, err := s.itemRepo.FindByPantryID(pantryID, exportPageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			code := ""
+			if item.Barcode != nil {
+				code = *item.Barcode
+			}
+			err := writeRow(item.ID.String(), item.Name, item.Description, item.Category.Name, item.Unit, code,
+				item.Quantity, item.AvailableQuantity, item.HeldQuantity, item.ExpiredQuantity,
+				item.LowStockThreshold, item.MaxPerOrder, item.ScaleLimitByHousehold, item.IsAvailable, item.ImageURL)
+			if err != nil {
+				return err
+			}
+		}
+		if len(items) < exportPageSize {
+			break
+		}
+	}
+
+	return finish()
+}
+
+// readSpreadsheet reads all rows of a CSV or XLSX file
+func readSpreadsheet(format string, file io.ReaderAt, size int64) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(io.NewSectionReader(file, 0, size))
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("file is not valid CSV: %w", err)
+		}
+		return records, nil
+	case FormatXLSX:
+		// The header plus one row per item; anything further is rejected while reading
+		records, err := xlsx.Read(file, size, maxImportRows+1, maxImportColumns)
+		if errors.Is(err, xlsx.ErrTooLarge) {
+			return nil, fmt.Errorf("imports are limited to %d items and %d columns", maxImportRows, maxImportColumns)
+		}
+		return records, err
+	default:
+		return nil, errors.New("format must be csv or xlsx")
+	}
+}
+
+// parseCount parses a non-negative whole number, allowing the trailing ".0"
+// spreadsheets add to numbers
+func parseCount(value string) (int, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f != math.Trunc(f) || f < 0 || f > math.MaxInt32 {
+		return 0, errors.New("must be a whole number of zero or more")
+	}
+	return int(f), nil
+}
+
+// parseYesNo parses the ways spreadsheets write booleans
+func parseYesNo(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "y", "1":
+		return true, nil
+	case "false", "no", "n", "0":
+		return false, nil
+	}
+	return false, errors.New("must be true or false")
+}
+
+// parseDate parses a YYYY-MM-DD date or an Excel date serial number
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 && serial < 2958466 {
+		epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+		return epoch.AddDate(0, 0, int(serial)), nil
+	}
+	return time.Time{}, errors.New("must be a date formatted as YYYY-MM-DD")
+}
+
+// ImportFormat picks the spreadsheet format of an uploaded file from an
+// explicit format or the file name's extension
+func ImportFormat(format, filename string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	switch {
+	case strings.HasSuffix(strings.ToLower(filename), ".xlsx"):
+		return FormatXLSX
+	case strings.HasSuffix(strings.ToLower(filename), ".csv"):
+		return FormatCSV
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"0", 0},
+		{"12", 12},
+		{"12.0", 12},
+		{"1e3", 1000},
+		{"2147483647", 2147483647},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseCount(tt.value)
+			if err != nil {
+				t.Fatalf("parseCount(%q) returned error: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseCount(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCountInvalid(t *testing.T) {
+	for _, value := range []string{"", "abc", "-1", "1.5", "2147483648", "NaN", "Inf", "1,000"} {
+		t.Run(value, func(t *testing.T) {
+			if got, err := parseCount(value); err == nil {
+				t.Errorf("parseCount(%q) = %d, want error", value, got)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{"ISO date", "2026-03-15", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "2024-02-29", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"Excel serial", "46096", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"Excel serial with time of day", "46096.75", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"first Excel serial after the 1900 leap bug", "61", time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDate(tt.value)
+			if err != nil {
+				t.Fatalf("parseDate(%q) returned error: %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseDate(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, value := range []string{"", "soon", "2023-02-29", "03/15/2026", "20260315", "0", "-5"} {
+		t.Run(value, func(t *testing.T) {
+			if got, err := parseDate(value); err == nil {
+				t.Errorf("parseDate(%q) = %s, want error", value, got)
+			}
+		})
+	}
+}
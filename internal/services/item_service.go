@@ -1,23 +1,45 @@
 package services
 
 import (
+	"bytes"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/barcode"
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/byte4bite/byte4bite/internal/repositories"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // ItemService handles item business logic
 type ItemService struct {
-	itemRepo *repositories.ItemRepository
+	itemRepo     *repositories.ItemRepository
+	movementRepo *repositories.StockMovementRepository
+	lotRepo      *repositories.ItemLotRepository
+	categoryRepo *repositories.CategoryRepository
+	catalogRepo  *repositories.CatalogRepository
+	stockService *StockService
+	txManager    *repositories.TxManager
 }
 
 // NewItemService creates a new item service
-func NewItemService(itemRepo *repositories.ItemRepository) *ItemService {
+func NewItemService(itemRepo *repositories.ItemRepository, movementRepo *repositories.StockMovementRepository, lotRepo *repositories.ItemLotRepository, categoryRepo *repositories.CategoryRepository, catalogRepo *repositories.CatalogRepository, stockService *StockService, txManager *repositories.TxManager) *ItemService {
 	return &ItemService{
-		itemRepo: itemRepo,
+		itemRepo:     itemRepo,
+		movementRepo: movementRepo,
+		lotRepo:      lotRepo,
+		categoryRepo: categoryRepo,
+		catalogRepo:  catalogRepo,
+		stockService: stockService,
+		txManager:    txManager,
 	}
 }
 
+// ErrNotEnoughStock is returned when a stock movement would take an item below zero
+var ErrNotEnoughStock = errors.New("not enough stock for this movement")
+
 // CreateItemRequest represents an item creation request
 type CreateItemRequest struct {
 	Name              string     `json:"name" binding:"required"`
@@ -29,6 +51,12 @@ type CreateItemRequest struct {
 	Unit              string     `json:"unit" binding:"required"`
 	ImageURL          string     `json:"image_url"`
 	IsAvailable       bool       `json:"is_available"`
+
+	MaxPerOrder           int  `json:"max_per_order" binding:"min=0"`
+	ScaleLimitByHousehold bool `json:"scale_limit_by_household"`
+
+	ExpiresAt *time.Time `json:"expires_at"` // Expiration date of the initial stock, if any
+	Barcode   string     `json:"barcode"`    // UPC or EAN
 }
 
 // UpdateItemRequest represents an item update request
@@ -36,11 +64,91 @@ type UpdateItemRequest struct {
 	Name              *string    `json:"name"`
 	Description       *string    `json:"description"`
 	CategoryID        *uuid.UUID `json:"category_id"`
-	Quantity          *int       `json:"quantity"`
+	Quantity          *int       `json:"quantity" binding:"omitempty,min=0"`
 	LowStockThreshold *int       `json:"low_stock_threshold"`
 	Unit              *string    `json:"unit"`
 	ImageURL          *string    `json:"image_url"`
 	IsAvailable       *bool      `json:"is_available"`
+
+	MaxPerOrder           *int  `json:"max_per_order" binding:"omitempty,min=0"`
+	ScaleLimitByHousehold *bool `json:"scale_limit_by_household"`
+
+	QuantityReason string `json:"quantity_reason"` // Recorded on the stock adjustment when Quantity changes
+
+	Barcode *string `json:"barcode"` // An empty string removes the barcode
+}
+
+// ScanIntakeRequest represents stock received by scanning a package's barcode
+type ScanIntakeRequest struct {
+	PantryID  uuid.UUID  `json:"pantry_id"` // Defaults to the staff member's pantry
+	Barcode   string     `json:"barcode" binding:"required"`
+	Quantity  int        `json:"quantity" binding:"omitempty,min=0"` // Defaults to 1
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Scan intake outcomes
+const (
+	ScanReceived = "received" // Stock was added to the matching item
+	ScanUnknown  = "unknown"  // No item has the barcode; create one from Prefill
+)
+
+// ScanIntakeResult reports the outcome of a scan. For unknown barcodes Prefill
+// holds a create item request filled in from the product catalog, when the
+// barcode is listed there.
+type ScanIntakeResult struct {
+	Status   string                 `json:"status"`
+	Barcode  string                 `json:"barcode"`
+	Item     *models.Item           `json:"item,omitempty"`
+	Received int                    `json:"received,omitempty"`
+	Product  *models.CatalogProduct `json:"product,omitempty"`
+	Prefill  *CreateItemRequest     `json:"prefill,omitempty"`
+}
+
+// StockMovementRequest represents a manual stock movement. Quantity is signed
+// for adjustments and a positive amount otherwise.
+type StockMovementRequest struct {
+	Type     models.StockMovementType `json:"type" binding:"required"`
+	Quantity int                      `json:"quantity" binding:"required"`
+	Reason   string                   `json:"reason"`
+	ToItemID *uuid.UUID               `json:"to_item_id"` // Required for transfers
+
+	// Receipts and positive adjustments create a lot with these details
+	ExpiresAt *time.Time `json:"expires_at"`
+	Source    string     `json:"source"`
+
+	// Spoilage and negative adjustments can name the lot they come from
+	LotID *uuid.UUID `json:"lot_id"`
+}
+
+// DiscardLotRequest represents discarding stock from a lot, usually expired food
+type DiscardLotRequest struct {
+	Quantity int    `json:"quantity" binding:"omitempty,min=0"` // Defaults to all that is left
+	Reason   string `json:"reason"`
+}
+
+// ExpiringReport lists lots that have expired or expire within a number of days,
+// earliest first
+type ExpiringReport struct {
+	Days     int              `json:"days"`
+	Before   time.Time        `json:"before"`
+	Expired  int              `json:"expired"`  // Units already past their date
+	Expiring int              `json:"expiring"` // Units expiring within the window
+	Lots     []models.ItemLot `json:"lots"`
+}
+
+// ListMovementsRequest represents a request to list an item's stock movements
+type ListMovementsRequest struct {
+	Type     *models.StockMovementType `form:"type"`
+	Page     int                       `form:"page"`
+	PageSize int                       `form:"page_size"`
+}
+
+// ListMovementsResponse represents a page of an item's stock movements
+type ListMovementsResponse struct {
+	Movements []models.StockMovement `json:"movements"`
+	Total     int64                  `json:"total"`
+	Page      int                    `json:"page"`
+	Pages     int                    `json:"pages"`
 }
 
 // ListItemsRequest represents a request to list items with filters
@@ -52,28 +160,63 @@ type ListItemsRequest struct {
 	LowStock   bool       `form:"low_stock"`
 	Page       int        `form:"page"`
 	PageSize   int        `form:"page_size"`
+
+	ActivePantry bool `form:"-"` // Set by the client-facing listing
 }
 
-// CreateItem creates a new item
-func (s *ItemService) CreateItem(req *CreateItemRequest) (*models.Item, error) {
+// CreateItem creates a new item, recording its initial stock as received
+func (s *ItemService) CreateItem(actorID uuid.UUID, req *CreateItemRequest) (*models.Item, error) {
+	code, err := s.checkBarcode(req.PantryID, uuid.Nil, req.Barcode)
+	if err != nil {
+		return nil, err
+	}
+
+	var item *models.Item
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		var err error
+		item, err = s.createItem(tx, actorID, req, code)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload to get associations
+	return s.itemRepo.FindByID(item.ID)
+}
+
+// createItem creates an item with an already checked barcode inside the
+// caller's transaction. The initial stock is added as the item's first lot.
+func (s *ItemService) createItem(tx *gorm.DB, actorID uuid.UUID, req *CreateItemRequest, code *string) (*models.Item, error) {
 	item := &models.Item{
 		Name:              req.Name,
 		Description:       req.Description,
 		CategoryID:        req.CategoryID,
 		PantryID:          req.PantryID,
-		Quantity:          req.Quantity,
 		LowStockThreshold: req.LowStockThreshold,
 		Unit:              req.Unit,
 		ImageURL:          req.ImageURL,
 		IsAvailable:       req.IsAvailable,
-	}
 
-	if err := s.itemRepo.Create(item); err != nil {
+		MaxPerOrder:           req.MaxPerOrder,
+		ScaleLimitByHousehold: req.ScaleLimitByHousehold,
+		Barcode:               code,
+	}
+	if err := s.itemRepo.WithTx(tx).Create(item); err != nil {
 		return nil, err
 	}
+	if req.Quantity == 0 {
+		return item, nil
+	}
 
-	// Reload to get associations
-	return s.itemRepo.FindByID(item.ID)
+	_, err := s.stockService.Receive(tx, item.ID, req.Quantity, LotDetails{ExpiresAt: req.ExpiresAt, Source: "initial stock"}, models.StockMovement{
+		ActorID: &actorID,
+		Reason:  "initial stock",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
 }
 
 // GetItem retrieves an item by ID
@@ -81,8 +224,8 @@ func (s *ItemService) GetItem(id uuid.UUID) (*models.Item, error) {
 	return s.itemRepo.FindByID(id)
 }
 
-// UpdateItem updates an item
-func (s *ItemService) UpdateItem(id uuid.UUID, req *UpdateItemRequest) (*models.Item, error) {
+// UpdateItem updates an item. A changed quantity is recorded as a stock adjustment.
+func (s *ItemService) UpdateItem(actorID, id uuid.UUID, req *UpdateItemRequest) (*models.Item, error) {
 	item, err := s.itemRepo.FindByID(id)
 	if err != nil {
 		return nil, err
@@ -98,9 +241,6 @@ func (s *ItemService) UpdateItem(id uuid.UUID, req *UpdateItemRequest) (*models.
 	if req.CategoryID != nil {
 		item.CategoryID = *req.CategoryID
 	}
-	if req.Quantity != nil {
-		item.Quantity = *req.Quantity
-	}
 	if req.LowStockThreshold != nil {
 		item.LowStockThreshold = *req.LowStockThreshold
 	}
@@ -113,8 +253,30 @@ func (s *ItemService) UpdateItem(id uuid.UUID, req *UpdateItemRequest) (*models.
 	if req.IsAvailable != nil {
 		item.IsAvailable = *req.IsAvailable
 	}
+	if req.MaxPerOrder != nil {
+		item.MaxPerOrder = *req.MaxPerOrder
+	}
+	if req.ScaleLimitByHousehold != nil {
+		item.ScaleLimitByHousehold = *req.ScaleLimitByHousehold
+	}
+	if req.Barcode != nil {
+		code, err := s.checkBarcode(item.PantryID, item.ID, *req.Barcode)
+		if err != nil {
+			return nil, err
+		}
+		item.Barcode = code
+	}
 
-	if err := s.itemRepo.Update(item); err != nil {
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		if err := s.itemRepo.WithTx(tx).Update(item); err != nil {
+			return err
+		}
+		if req.Quantity == nil {
+			return nil
+		}
+		return s.setQuantity(tx, actorID, id, *req.Quantity, req.QuantityReason)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -155,6 +317,8 @@ func (s *ItemService) ListItems(req *ListItemsRequest) ([]models.Item, int64, er
 		Search:     req.Search,
 		Available:  req.Available,
 		LowStock:   req.LowStock,
+
+		ActivePantry: req.ActivePantry,
 	}
 
 	items, err := s.itemRepo.List(filter, pageSize, offset)
@@ -175,29 +339,361 @@ func (s *ItemService) GetLowStockItems(pantryID *uuid.UUID) ([]models.Item, erro
 	return s.itemRepo.FindLowStock(pantryID)
 }
 
-// UpdateItemQuantity updates the quantity of an item
-func (s *ItemService) UpdateItemQuantity(id uuid.UUID, quantity int) error {
-	// Verify item exists
-	_, err := s.itemRepo.FindByID(id)
+// UpdateItemQuantity sets the quantity of an item after a stock count,
+// recording the difference as an adjustment
+func (s *ItemService) UpdateItemQuantity(actorID, id uuid.UUID, quantity int, reason string) error {
+	return s.txManager.Transaction(func(tx *gorm.DB) error {
+		return s.setQuantity(tx, actorID, id, quantity, reason)
+	})
+}
+
+// RecordMovement applies a manual stock movement to an item. Distributions and
+// returns are recorded by orders and cannot be entered by hand. Receipts create
+// a new lot; spoilage draws expired lots first. A transfer moves stock to
+// another item, typically the same product at another pantry, keeping each
+// lot's expiration date.
+func (s *ItemService) RecordMovement(actorID, id uuid.UUID, req *StockMovementRequest) (*models.Item, error) {
+	switch req.Type {
+	case models.StockReceive, models.StockSpoilage, models.StockTransfer:
+		if req.Quantity <= 0 {
+			return nil, errors.New("quantity must be positive")
+		}
+	case models.StockAdjust:
+		if req.Quantity == 0 {
+			return nil, errors.New("quantity must not be zero")
+		}
+	case models.StockDistribute, models.StockReturn:
+		return nil, errors.New("distributions and returns are recorded by orders")
+	default:
+		return nil, errors.New("type must be receive, adjust, spoilage or transfer")
+	}
+
+	if req.Type == models.StockTransfer && (req.ToItemID == nil || *req.ToItemID == id) {
+		return nil, errors.New("transfers require a different to_item_id")
+	}
+
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		ref := models.StockMovement{Type: req.Type, ActorID: &actorID, Reason: req.Reason}
+		details := LotDetails{ExpiresAt: req.ExpiresAt, Source: req.Source}
+
+		switch {
+		case req.Type == models.StockReceive || (req.Type == models.StockAdjust && req.Quantity > 0):
+			if details.Source == "" {
+				details.Source = string(req.Type)
+			}
+			_, err := s.stockService.Receive(tx, id, req.Quantity, details, ref)
+			return err
+
+		case req.Type == models.StockTransfer:
+			return s.transfer(tx, id, *req.ToItemID, req.Quantity, ref)
+
+		default:
+			// Spoilage or a negative adjustment
+			quantity := req.Quantity
+			if quantity < 0 {
+				quantity = -quantity
+			}
+			allocations, err := s.stockService.Remove(tx, id, req.LotID, quantity, false, ref)
+			if err != nil {
+				return err
+			}
+			if allocations == nil {
+				return ErrNotEnoughStock
+			}
+			return nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.itemRepo.FindByID(id)
+}
+
+// transfer moves unexpired stock from one item to another, lot by lot
+func (s *ItemService) transfer(tx *gorm.DB, fromID, toID uuid.UUID, quantity int, ref models.StockMovement) error {
+	// Lock both items in a stable order so opposite transfers cannot deadlock
+	itemRepo := s.itemRepo.WithTx(tx)
+	first, second := fromID, toID
+	if bytes.Compare(second[:], first[:]) < 0 {
+		first, second = second, first
+	}
+	for _, itemID := range []uuid.UUID{first, second} {
+		if _, err := itemRepo.FindForUpdate(itemID); err != nil {
+			return err
+		}
+	}
+
+	out := ref
+	out.TransferItemID = &toID
+	allocations, err := s.stockService.Remove(tx, fromID, nil, quantity, true, out)
 	if err != nil {
 		return err
 	}
+	if allocations == nil {
+		return ErrNotEnoughStock
+	}
 
-	return s.itemRepo.UpdateQuantity(id, quantity)
+	in := ref
+	in.TransferItemID = &fromID
+	for _, allocation := range allocations {
+		details := LotDetails{ExpiresAt: allocation.Lot.ExpiresAt, Source: "transfer"}
+		if _, err := s.stockService.Receive(tx, toID, allocation.Quantity, details, in); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// AdjustItemQuantity adjusts the quantity of an item by a delta
-func (s *ItemService) AdjustItemQuantity(id uuid.UUID, delta int) error {
-	// Verify item exists
-	item, err := s.itemRepo.FindByID(id)
+// GetItemByBarcode finds a pantry's item by its barcode
+func (s *ItemService) GetItemByBarcode(pantryID uuid.UUID, code string) (*models.Item, error) {
+	code, err := barcode.Normalize(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.itemRepo.FindByBarcode(pantryID, code)
+}
+
+// ScanIntake receives stock for a scanned barcode. When no item of the pantry
+// has the barcode nothing is received and the result carries a prefilled
+// request to create the item instead.
+func (s *ItemService) ScanIntake(actorID uuid.UUID, req *ScanIntakeRequest) (*ScanIntakeResult, error) {
+	code, err := barcode.Normalize(req.Barcode)
+	if err != nil {
+		return nil, err
+	}
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	item, err := s.itemRepo.FindByBarcode(req.PantryID, code)
+	if err != nil {
+		return s.unknownScan(req.PantryID, code, quantity, req.ExpiresAt)
+	}
+
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		_, err := s.stockService.Receive(tx, item.ID, quantity, LotDetails{ExpiresAt: req.ExpiresAt, Source: "scan"}, models.StockMovement{
+			ActorID: &actorID,
+			Reason:  "scan intake",
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	item, err = s.itemRepo.FindByID(item.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &ScanIntakeResult{Status: ScanReceived, Barcode: code, Item: item, Received: quantity}, nil
+}
+
+// unknownScan builds the create item request offered for an unknown barcode
+func (s *ItemService) unknownScan(pantryID uuid.UUID, code string, quantity int, expiresAt *time.Time) (*ScanIntakeResult, error) {
+	prefill := &CreateItemRequest{
+		PantryID:          pantryID,
+		Quantity:          quantity,
+		LowStockThreshold: 10,
+		Unit:              "count",
+		IsAvailable:       true,
+		ExpiresAt:         expiresAt,
+		Barcode:           code,
+	}
+	result := &ScanIntakeResult{Status: ScanUnknown, Barcode: code, Prefill: prefill}
+
+	product, err := s.catalogRepo.FindByBarcode(code)
+	if err != nil {
+		return result, nil
+	}
+	result.Product = product
+	prefill.Name = product.Name
+	if product.Brand != "" && !strings.Contains(strings.ToLower(product.Name), strings.ToLower(product.Brand)) {
+		prefill.Name = product.Brand + " " + product.Name
+	}
+	prefill.Description = product.Description
+	prefill.ImageURL = product.ImageURL
+	if product.Unit != "" {
+		prefill.Unit = product.Unit
+	}
+
+	if product.CategoryName != "" {
+		categories, err := s.categoryRepo.FindByPantryID(pantryID)
+		if err != nil {
+			return nil, err
+		}
+		for _, category := range categories {
+			if strings.EqualFold(category.Name, product.CategoryName) {
+				prefill.CategoryID = category.ID
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// checkBarcode validates a barcode for an item of a pantry, rejecting codes
+// already used by another of its items. An empty code means no barcode.
+func (s *ItemService) checkBarcode(pantryID, itemID uuid.UUID, code string) (*string, error) {
+	if strings.TrimSpace(code) == "" {
+		return nil, nil
+	}
+	code, err := barcode.Normalize(code)
+	if err != nil {
+		return nil, err
+	}
+	if other, err := s.itemRepo.FindByBarcode(pantryID, code); err == nil && other.ID != itemID {
+		return nil, errors.New("barcode is already used by " + other.Name)
+	}
+	return &code, nil
+}
+
+// GetLots lists the lots of an item that still hold stock, earliest expiring first
+func (s *ItemService) GetLots(id uuid.UUID) ([]models.ItemLot, error) {
+	if _, err := s.itemRepo.FindByID(id); err != nil {
+		return nil, err
+	}
+	return s.lotRepo.FindByItemID(id)
+}
+
+// GetExpiring reports the lots that have expired or expire within the given
+// number of days, optionally for a single pantry
+func (s *ItemService) GetExpiring(pantryID *uuid.UUID, days int) (*ExpiringReport, error) {
+	if days < 0 {
+		return nil, errors.New("days must not be negative")
+	}
+
+	now := time.Now()
+	before := now.AddDate(0, 0, days)
+	lots, err := s.lotRepo.FindExpiring(pantryID, before)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ExpiringReport{Days: days, Before: before, Lots: lots}
+	for _, lot := range lots {
+		if lot.Expired || !lot.ExpiresAt.After(now) {
+			report.Expired += lot.Quantity
+		} else {
+			report.Expiring += lot.Quantity
+		}
+	}
+	return report, nil
+}
+
+// DiscardLot removes stock from a lot as spoilage, all of it by default
+func (s *ItemService) DiscardLot(actorID, lotID uuid.UUID, req *DiscardLotRequest) (*models.ItemLot, error) {
+	lot, err := s.lotRepo.FindByID(lotID)
+	if err != nil {
+		return nil, err
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = lot.Quantity
+	}
+	if quantity == 0 {
+		return nil, errors.New("lot has no stock left")
+	}
+	reason := req.Reason
+	if reason == "" {
+		reason = "discarded"
+	}
+
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		allocations, err := s.stockService.Remove(tx, lot.ItemID, &lot.ID, quantity, false, models.StockMovement{
+			Type:    models.StockSpoilage,
+			ActorID: &actorID,
+			Reason:  reason,
+		})
+		if err != nil {
+			return err
+		}
+		if allocations == nil {
+			return ErrNotEnoughStock
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.lotRepo.FindByID(lot.ID)
+}
+
+// GetMovements lists an item's stock movements, newest first
+func (s *ItemService) GetMovements(id uuid.UUID, req *ListMovementsRequest) (*ListMovementsResponse, error) {
+	if _, err := s.itemRepo.FindByID(id); err != nil {
+		return nil, err
+	}
+	if req.Type != nil && !req.Type.IsValid() {
+		return nil, errors.New("invalid movement type")
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	offset := (req.Page - 1) * req.PageSize
+
+	movements, err := s.movementRepo.FindByItemID(id, req.Type, req.PageSize, offset)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.movementRepo.CountByItemID(id, req.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := int(total) / req.PageSize
+	if int(total)%req.PageSize != 0 {
+		pages++
+	}
+
+	return &ListMovementsResponse{
+		Movements: movements,
+		Total:     total,
+		Page:      req.Page,
+		Pages:     pages,
+	}, nil
+}
+
+// setQuantity sets an item's quantity to a counted value and records the
+// difference as an adjustment
+func (s *ItemService) setQuantity(tx *gorm.DB, actorID, id uuid.UUID, quantity int, reason string) error {
+	if quantity < 0 {
+		return errors.New("quantity must not be negative")
+	}
+
+	itemRepo := s.itemRepo.WithTx(tx)
+	item, err := itemRepo.FindForUpdate(id)
 	if err != nil {
 		return err
 	}
+	delta := quantity - item.Quantity
+	if delta == 0 {
+		return nil
+	}
 
-	// Check that adjustment won't result in negative quantity
-	if item.Quantity+delta < 0 {
+	if reason == "" {
+		reason = "stock count"
+	}
+	ref := models.StockMovement{Type: models.StockAdjust, ActorID: &actorID, Reason: reason}
+	if delta > 0 {
+		_, err := s.stockService.Receive(tx, id, delta, LotDetails{Source: "stock count"}, ref)
 		return err
 	}
 
-	return s.itemRepo.AdjustQuantity(id, delta)
+	// A count below the books comes out of expired lots first
+	allocations, err := s.stockService.Remove(tx, id, nil, -delta, false, ref)
+	if err != nil {
+		return err
+	}
+	if allocations == nil {
+		return ErrNotEnoughStock
+	}
+	return nil
 }
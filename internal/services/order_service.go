@@ -1,33 +1,66 @@
 package services
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/byte4bite/byte4bite/internal/repositories"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// ErrInvalidOrderSort is returned when orders are listed with an unknown sort option
+var ErrInvalidOrderSort = errors.New("sort must be one of created_at, submitted_at, pickup_start or status, optionally prefixed with -")
+
 // OrderService handles business logic for orders
 type OrderService struct {
-	orderRepo *repositories.OrderRepository
-	itemRepo  *repositories.ItemRepository
+	orderRepo    *repositories.OrderRepository
+	cartRepo     *repositories.CartRepository
+	itemRepo     *repositories.ItemRepository
+	userRepo     *repositories.UserRepository
+	slotRepo     *repositories.PickupSlotRepository
+	eventRepo    *repositories.OrderEventRepository
+	workflowRepo *repositories.WorkflowRepository
+	stockService *StockService
+	txManager    *repositories.TxManager
+	staffService *StaffService
 }
 
 // NewOrderService creates a new order service
-func NewOrderService(orderRepo *repositories.OrderRepository, itemRepo *repositories.ItemRepository) *OrderService {
+func NewOrderService(orderRepo *repositories.OrderRepository, cartRepo *repositories.CartRepository, itemRepo *repositories.ItemRepository, userRepo *repositories.UserRepository, slotRepo *repositories.PickupSlotRepository, eventRepo *repositories.OrderEventRepository, workflowRepo *repositories.WorkflowRepository, stockService *StockService, txManager *repositories.TxManager, staffService *StaffService) *OrderService {
 	return &OrderService{
-		orderRepo: orderRepo,
-		itemRepo:  itemRepo,
+		orderRepo:    orderRepo,
+		cartRepo:     cartRepo,
+		itemRepo:     itemRepo,
+		userRepo:     userRepo,
+		slotRepo:     slotRepo,
+		eventRepo:    eventRepo,
+		workflowRepo: workflowRepo,
+		stockService: stockService,
+		txManager:    txManager,
+		staffService: staffService,
 	}
 }
 
-// GetOrderRequest represents the request to get orders
+// GetOrderRequest represents the request to get orders. Filters other than
+// Status only apply to the admin listing.
 type GetOrdersRequest struct {
-	Status   *models.OrderStatus
-	Page     int
-	PageSize int
+	Status        *models.OrderStatus
+	PantryID      *uuid.UUID
+	SubmittedFrom *time.Time
+	SubmittedTo   *time.Time
+	AssignedToID  *uuid.UUID
+	Unassigned    bool
+	Search        string
+	PickupSlotID  *uuid.UUID
+	Sort          string
+	Page          int
+	PageSize      int
 }
 
 // GetOrdersResponse represents the response containing orders
@@ -41,6 +74,37 @@ type GetOrdersResponse struct {
 // UpdateStatusRequest represents a request to update order status
 type UpdateStatusRequest struct {
 	Status models.OrderStatus `json:"status" binding:"required"`
+	Reason string             `json:"reason"`
+}
+
+// AdjustOrderLineRequest represents a staff change to the quantity of an order line
+type AdjustOrderLineRequest struct {
+	Quantity *int   `json:"quantity" binding:"required,min=0"`
+	Reason   string `json:"reason"`
+}
+
+// SubstituteOrderLineRequest represents a staff substitution of an order line's item
+type SubstituteOrderLineRequest struct {
+	ItemID   uuid.UUID `json:"item_id" binding:"required"`
+	Quantity int       `json:"quantity" binding:"required,min=1"`
+	Reason   string    `json:"reason"`
+}
+
+// OrderItemChange sets the quantity of one item on an order; 0 removes it
+type OrderItemChange struct {
+	ItemID   uuid.UUID `json:"item_id" binding:"required"`
+	Quantity *int      `json:"quantity" binding:"required,min=0"`
+}
+
+// UpdateOrderItemsRequest represents a client's changes to a pending order.
+// Items not listed are left as they are.
+type UpdateOrderItemsRequest struct {
+	Items []OrderItemChange `json:"items" binding:"required,min=1,dive"`
+}
+
+// CancelOrderRequest represents an optional reason given when cancelling an order
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
 }
 
 // AssignStaffRequest represents a request to assign staff to an order
@@ -48,6 +112,39 @@ type AssignStaffRequest struct {
 	StaffID uuid.UUID `json:"staff_id" binding:"required"`
 }
 
+// BulkOrderAction is an operation applied to several orders at once
+type BulkOrderAction string
+
+const (
+	BulkActionStatus BulkOrderAction = "status"
+	BulkActionAssign BulkOrderAction = "assign"
+	BulkActionCancel BulkOrderAction = "cancel"
+)
+
+// BulkOrderRequest represents an action to apply to a list of orders
+type BulkOrderRequest struct {
+	OrderIDs []uuid.UUID        `json:"order_ids" binding:"required,min=1,max=200"`
+	Action   BulkOrderAction    `json:"action" binding:"required"`
+	Status   models.OrderStatus `json:"status"`   // Required for the status action
+	StaffID  *uuid.UUID         `json:"staff_id"` // Required for the assign action
+	Reason   string             `json:"reason"`
+}
+
+// BulkOrderResult reports the outcome of a bulk action for one order
+type BulkOrderResult struct {
+	OrderID uuid.UUID `json:"order_id"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// BulkOrderResponse reports the outcome of a bulk action
+type BulkOrderResponse struct {
+	Action    BulkOrderAction   `json:"action"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BulkOrderResult `json:"results"`
+}
+
 // GetOrders returns a list of orders
 func (s *OrderService) GetOrders(userID uuid.UUID, isAdmin bool, req GetOrdersRequest) (*GetOrdersResponse, error) {
 	if req.Page < 1 {
@@ -65,11 +162,27 @@ func (s *OrderService) GetOrders(userID uuid.UUID, isAdmin bool, req GetOrdersRe
 
 	if isAdmin {
 		// Admin can see all orders
-		orders, err = s.orderRepo.FindAll(req.Status, req.PageSize, offset)
+		if req.Sort != "" {
+			if _, ok := repositories.OrderSortFields[strings.TrimPrefix(req.Sort, "-")]; !ok {
+				return nil, ErrInvalidOrderSort
+			}
+		}
+		filter := repositories.OrderFilter{
+			Status:        req.Status,
+			PantryID:      req.PantryID,
+			SubmittedFrom: req.SubmittedFrom,
+			SubmittedTo:   req.SubmittedTo,
+			AssignedToID:  req.AssignedToID,
+			Unassigned:    req.Unassigned,
+			Search:        strings.TrimSpace(req.Search),
+			PickupSlotID:  req.PickupSlotID,
+			Sort:          req.Sort,
+		}
+		orders, err = s.orderRepo.FindAll(filter, req.PageSize, offset)
 		if err != nil {
 			return nil, err
 		}
-		total, err = s.orderRepo.CountAll(req.Status)
+		total, err = s.orderRepo.CountAll(filter)
 	} else {
 		// Users can only see their own orders
 		orders, err = s.orderRepo.FindByUserID(userID, req.PageSize, offset)
@@ -104,118 +217,568 @@ func (s *OrderService) GetOrder(orderID uuid.UUID, userID uuid.UUID, isAdmin boo
 	}
 
 	// Check permissions: users can only view their own orders
-	if !isAdmin && order.UserID != userID {
+	if !isAdmin && !order.IsOwnedBy(userID) {
 		return nil, errors.New("unauthorized to view this order")
 	}
 
 	return order, nil
 }
 
-// UpdateOrderStatus updates the status of an order with validation
-func (s *OrderService) UpdateOrderStatus(orderID uuid.UUID, newStatus models.OrderStatus) error {
-	order, err := s.orderRepo.FindByID(orderID)
-	if err != nil {
-		return err
+// GetOrderHistory returns the recorded events of an order, oldest first
+func (s *OrderService) GetOrderHistory(orderID uuid.UUID, userID uuid.UUID, isAdmin bool) ([]models.OrderEvent, error) {
+	if _, err := s.GetOrder(orderID, userID, isAdmin); err != nil {
+		return nil, err
 	}
+	return s.eventRepo.FindByOrderID(orderID)
+}
+
+// UpdateOrderStatus moves an order to a new status along its pantry's workflow
+func (s *OrderService) UpdateOrderStatus(orderID uuid.UUID, actorID uuid.UUID, newStatus models.OrderStatus, reason string) error {
+	return s.txManager.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(s.orderRepo.WithTx(tx), orderID)
+		if err != nil {
+			return err
+		}
+
+		workflow, err := s.workflowRepo.FindForPantry(order.PantryID, order.FulfillmentType)
+		if err != nil {
+			return err
+		}
+
+		// Validate status transition
+		if !isValidStatusTransition(workflow, order.Status, newStatus) {
+			return errors.New("invalid status transition")
+		}
+
+		return s.applyTransition(tx, workflow, order, workflow.FindTransition(order.Status, newStatus), &actorID, reason)
+	})
+}
+
+// AssignStaff assigns an order to a staff member
+func (s *OrderService) AssignStaff(orderID uuid.UUID, actorID uuid.UUID, staffID uuid.UUID) error {
+	return s.txManager.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(s.orderRepo.WithTx(tx), orderID)
+		if err != nil {
+			return err
+		}
+
+		workflow, err := s.workflowRepo.FindForPantry(order.PantryID, order.FulfillmentType)
+		if err != nil {
+			return err
+		}
+
+		// Can't assign orders that are already closed
+		if workflow.IsFinal(order.Status) {
+			return errors.New("cannot assign staff to cancelled or completed orders")
+		}
+		if err := s.staffService.ValidateStaff(staffID, order.PantryID); err != nil {
+			return err
+		}
+
+		order.AssignedToID = &staffID
+		if err := s.orderRepo.WithTx(tx).Update(order); err != nil {
+			return err
+		}
+		return s.eventRepo.WithTx(tx).Create(&models.OrderEvent{
+			OrderID:      order.ID,
+			Type:         models.OrderEventAssignment,
+			ActorID:      &actorID,
+			FromStatus:   order.Status,
+			ToStatus:     order.Status,
+			AssignedToID: &staffID,
+		})
+	})
+}
+
+// CancelOrder cancels an order. Admins may cancel wherever the workflow allows a
+// move to cancelled; clients only where that transition is marked client-allowed.
+func (s *OrderService) CancelOrder(orderID uuid.UUID, userID uuid.UUID, isAdmin bool, reason string) error {
+	return s.txManager.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(s.orderRepo.WithTx(tx), orderID)
+		if err != nil {
+			return err
+		}
+
+		// Check permissions
+		if !isAdmin && !order.IsOwnedBy(userID) {
+			return errors.New("unauthorized to cancel this order")
+		}
+
+		workflow, err := s.workflowRepo.FindForPantry(order.PantryID, order.FulfillmentType)
+		if err != nil {
+			return err
+		}
+
+		transition := workflow.FindTransition(order.Status, models.OrderStatusCancelled)
+		if transition == nil || (!isAdmin && !transition.ClientAllowed) {
+			return errors.New("order can no longer be cancelled")
+		}
+
+		return s.applyTransition(tx, workflow, order, transition, &userID, reason)
+	})
+}
+
+// UpdateOrderItems lets a client add, remove or change the quantity of items
+// on their own order while it is still in its workflow's initial status.
+// Availability and per-order limits are checked as when adding to a cart, and
+// inventory is reconciled for the difference.
+func (s *OrderService) UpdateOrderItems(orderID, userID uuid.UUID, req *UpdateOrderItemsRequest) (*models.Order, error) {
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(s.orderRepo.WithTx(tx), orderID)
+		if err != nil {
+			return err
+		}
+		if !order.IsOwnedBy(userID) {
+			return errors.New("unauthorized to edit this order")
+		}
+		workflow, err := s.workflowRepo.FindForPantry(order.PantryID, order.FulfillmentType)
+		if err != nil {
+			return err
+		}
+		if order.Status != workflow.InitialStatus {
+			return errors.New("order can only be changed until the pantry starts processing it")
+		}
+
+		itemRepo := s.itemRepo.WithTx(tx)
+		lines := make([]models.CartItem, len(order.Cart.Items))
+		copy(lines, order.Cart.Items)
+
+		// Stock changes per item; positive deltas are taken from inventory
+		deltas := make(map[uuid.UUID]int)
+		var changes []string
+		for _, change := range req.Items {
+			if _, seen := deltas[change.ItemID]; seen {
+				return errors.New("each item may only be listed once")
+			}
+			quantity := *change.Quantity
+
+			index := -1
+			for i := range lines {
+				if lines[i].ItemID == change.ItemID {
+					index = i
+					break
+				}
+			}
+
+			if index < 0 {
+				if quantity == 0 {
+					continue
+				}
+				item, err := itemRepo.FindByID(change.ItemID)
+				if err != nil {
+					return err
+				}
+				if item.PantryID != order.PantryID {
+					return errors.New("item does not belong to the order's pantry")
+				}
+				if !item.IsAvailable {
+					return errors.New("item is not available: " + item.Name)
+				}
+				lines = append(lines, models.CartItem{CartID: order.CartID, ItemID: item.ID, Item: *item})
+				index = len(lines) - 1
+			}
+
+			line := &lines[index]
+			delta := quantity - line.Quantity
+			if delta == 0 {
+				continue
+			}
+			if delta > 0 && !line.Item.IsAvailable {
+				return errors.New("item is not available: " + line.Item.Name)
+			}
+
+			switch {
+			case line.Quantity == 0:
+				changes = append(changes, fmt.Sprintf("%s added (%d)", line.Item.Name, quantity))
+			case quantity == 0:
+				changes = append(changes, fmt.Sprintf("%s removed (was %d)", line.Item.Name, line.Quantity))
+			default:
+				changes = append(changes, fmt.Sprintf("%s changed from %d to %d", line.Item.Name, line.Quantity, quantity))
+			}
+			deltas[line.ItemID] = delta
+			line.Quantity = quantity
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		var remaining []models.CartItem
+		for _, line := range lines {
+			if line.Quantity > 0 {
+				remaining = append(remaining, line)
+			}
+		}
+		if len(remaining) == 0 {
+			return errors.New("an order must keep at least one item; cancel the order instead")
+		}
+		if err := checkQuantityLimits(remaining, order.HouseholdSize()); err != nil {
+			return err
+		}
+
+		// Adjust stock in a stable order so concurrent edits and checkouts lock
+		// item rows in the same sequence and cannot deadlock
+		sort.Slice(lines, func(i, j int) bool {
+			return bytes.Compare(lines[i].ItemID[:], lines[j].ItemID[:]) < 0
+		})
+
+		var outOfStock []string
+		cartRepo := s.cartRepo.WithTx(tx)
+		ref := models.StockMovement{ActorID: &userID, OrderID: &order.ID, Reason: "order edited by client"}
+		for i := range lines {
+			line := &lines[i]
+			delta, changed := deltas[line.ItemID]
+			if !changed {
+				continue
+			}
+
+			if delta > 0 {
+				ok, err := s.stockService.Take(tx, line.ItemID, delta, ref)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					outOfStock = append(outOfStock, line.Item.Name)
+					continue
+				}
+			} else if err := s.stockService.Return(tx, line.ItemID, -delta, ref); err != nil {
+				return err
+			}
+
+			// The client is still choosing, so the new quantity is what they asked for
+			line.RequestedQuantity = line.Quantity
+			switch {
+			case line.Quantity == 0:
+				err = cartRepo.RemoveItem(line.ID)
+			case line.ID == uuid.Nil:
+				err = cartRepo.AddItem(&models.CartItem{
+					CartID:            line.CartID,
+					ItemID:            line.ItemID,
+					Quantity:          line.Quantity,
+					RequestedQuantity: line.Quantity,
+				})
+			default:
+				err = cartRepo.UpdateItem(line)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if len(outOfStock) > 0 {
+			return &InsufficientStockError{Items: outOfStock}
+		}
 
-	// Validate status transition
-	if !isValidStatusTransition(order.Status, newStatus) {
-		return errors.New("invalid status transition")
+		return s.recordLineChange(tx, order, userID, strings.Join(changes, ", "), "")
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Update the status
-	order.Status = newStatus
+	return s.orderRepo.FindByID(orderID)
+}
 
-	// Set timestamps based on status
-	now := time.Now()
-	switch newStatus {
-	case models.OrderStatusReady:
-		order.ReadyAt = &now
-	case models.OrderStatusPickedUp:
-		order.PickedUpAt = &now
+// BulkUpdate applies one action to each listed order in its own transaction,
+// so an order that cannot be changed does not hold back the others
+func (s *OrderService) BulkUpdate(actorID uuid.UUID, req *BulkOrderRequest) (*BulkOrderResponse, error) {
+	var apply func(orderID uuid.UUID) error
+	switch req.Action {
+	case BulkActionStatus:
+		if req.Status == "" {
+			return nil, errors.New("status is required for the status action")
+		}
+		apply = func(orderID uuid.UUID) error {
+			return s.UpdateOrderStatus(orderID, actorID, req.Status, req.Reason)
+		}
+	case BulkActionAssign:
+		if req.StaffID == nil {
+			return nil, errors.New("staff_id is required for the assign action")
+		}
+		apply = func(orderID uuid.UUID) error {
+			return s.AssignStaff(orderID, actorID, *req.StaffID)
+		}
+	case BulkActionCancel:
+		apply = func(orderID uuid.UUID) error {
+			return s.CancelOrder(orderID, actorID, true, req.Reason)
+		}
+	default:
+		return nil, errors.New("action must be status, assign or cancel")
+	}
+
+	response := &BulkOrderResponse{Action: req.Action, Results: []BulkOrderResult{}}
+	seen := make(map[uuid.UUID]bool)
+	for _, orderID := range req.OrderIDs {
+		if seen[orderID] {
+			continue
+		}
+		seen[orderID] = true
+
+		result := BulkOrderResult{OrderID: orderID, Success: true}
+		if err := apply(orderID); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+		response.Results = append(response.Results, result)
 	}
 
-	return s.orderRepo.Update(order)
+	return response, nil
 }
 
-// AssignStaff assigns an order to a staff member
-func (s *OrderService) AssignStaff(orderID uuid.UUID, staffID uuid.UUID) error {
-	order, err := s.orderRepo.FindByID(orderID)
+// AdjustOrderLine changes the fulfilled quantity of an order line while the
+// order is in a staff-editable state; a quantity of 0 removes the line from the order but
+// keeps it on record. Inventory is reconciled for the difference.
+func (s *OrderService) AdjustOrderLine(orderID, lineID, actorID uuid.UUID, quantity int, reason string) (*models.Order, error) {
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		order, line, err := s.lockEditableLine(tx, orderID, lineID)
+		if err != nil {
+			return err
+		}
+
+		fromQuantity := line.Quantity
+		delta := quantity - fromQuantity
+		if delta == 0 {
+			return nil
+		}
+
+		ref := models.StockMovement{ActorID: &actorID, OrderID: &order.ID, Reason: reason}
+		if delta > 0 {
+			ok, err := s.stockService.Take(tx, line.ItemID, delta, ref)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return &InsufficientStockError{Items: []string{line.Item.Name}}
+			}
+		} else if err := s.stockService.Return(tx, line.ItemID, -delta, ref); err != nil {
+			return err
+		}
+
+		line.Quantity = quantity
+		if err := s.cartRepo.WithTx(tx).UpdateItem(line); err != nil {
+			return err
+		}
+
+		change := fmt.Sprintf("%s changed from %d to %d", line.Item.Name, fromQuantity, quantity)
+		if quantity == 0 {
+			change = fmt.Sprintf("%s removed (was %d)", line.Item.Name, fromQuantity)
+		}
+		return s.recordLineChange(tx, order, actorID, change, reason)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Can't assign cancelled or picked up orders
-	if order.Status == models.OrderStatusCancelled || order.Status == models.OrderStatusPickedUp {
-		return errors.New("cannot assign staff to cancelled or completed orders")
+	return s.orderRepo.FindByID(orderID)
+}
+
+// SubstituteOrderLine replaces the item of an order line while the order is
+// in a staff-editable state. The original item is restocked and the substitute taken
+// from inventory; the line remembers which item the client asked for.
+func (s *OrderService) SubstituteOrderLine(orderID, lineID, actorID uuid.UUID, req *SubstituteOrderLineRequest) (*models.Order, error) {
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		order, line, err := s.lockEditableLine(tx, orderID, lineID)
+		if err != nil {
+			return err
+		}
+		if req.ItemID == line.ItemID {
+			return errors.New("substitute must be a different item")
+		}
+		for _, other := range order.Cart.Items {
+			if other.ItemID == req.ItemID {
+				return errors.New("substitute is already on the order; adjust that line instead")
+			}
+		}
+
+		itemRepo := s.itemRepo.WithTx(tx)
+		substitute, err := itemRepo.FindByID(req.ItemID)
+		if err != nil {
+			return err
+		}
+		if substitute.PantryID != order.PantryID {
+			return errors.New("substitute item must belong to the order's pantry")
+		}
+
+		ref := models.StockMovement{ActorID: &actorID, OrderID: &order.ID, Reason: req.Reason}
+		if err := s.stockService.Return(tx, line.ItemID, line.Quantity, ref); err != nil {
+			return err
+		}
+		ok, err := s.stockService.Take(tx, substitute.ID, req.Quantity, ref)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return &InsufficientStockError{Items: []string{substitute.Name}}
+		}
+
+		change := fmt.Sprintf("%s (%d) substituted with %s (%d)", line.Item.Name, line.Quantity, substitute.Name, req.Quantity)
+
+		// Keep pointing at what the client originally asked for across repeated substitutions
+		if line.SubstitutedForID == nil {
+			original := line.ItemID
+			line.SubstitutedForID = &original
+		}
+		if *line.SubstitutedForID == substitute.ID {
+			line.SubstitutedForID = nil
+		}
+		line.ItemID = substitute.ID
+		line.Quantity = req.Quantity
+		if err := s.cartRepo.WithTx(tx).UpdateItem(line); err != nil {
+			return err
+		}
+
+		return s.recordLineChange(tx, order, actorID, change, req.Reason)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	order.AssignedToID = &staffID
-	return s.orderRepo.Update(order)
+	return s.orderRepo.FindByID(orderID)
 }
 
-// CancelOrder cancels an order
-func (s *OrderService) CancelOrder(orderID uuid.UUID, userID uuid.UUID, isAdmin bool) error {
-	order, err := s.orderRepo.FindByID(orderID)
+// lockEditableLine locks an order that staff may still edit and finds one of its lines
+func (s *OrderService) lockEditableLine(tx *gorm.DB, orderID, lineID uuid.UUID) (*models.Order, *models.CartItem, error) {
+	order, err := lockOrder(s.orderRepo.WithTx(tx), orderID)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	workflow, err := s.workflowRepo.FindForPantry(order.PantryID, order.FulfillmentType)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !workflow.IsStaffEditable(order.Status) {
+		return nil, nil, errors.New("order items cannot be changed in the order's current status")
 	}
 
-	// Check permissions
-	if !isAdmin && order.UserID != userID {
-		return errors.New("unauthorized to cancel this order")
+	for i := range order.Cart.Items {
+		if order.Cart.Items[i].ID == lineID {
+			return order, &order.Cart.Items[i], nil
+		}
 	}
+	return nil, nil, errors.New("order line not found")
+}
 
-	// Can only cancel pending or preparing orders
-	if order.Status != models.OrderStatusPending && order.Status != models.OrderStatusPreparing {
-		return errors.New("can only cancel pending or preparing orders")
+// recordLineChange records an item adjustment in the order history
+func (s *OrderService) recordLineChange(tx *gorm.DB, order *models.Order, actorID uuid.UUID, change, reason string) error {
+	if reason != "" {
+		change += ": " + reason
 	}
+	return s.eventRepo.WithTx(tx).Create(&models.OrderEvent{
+		OrderID:    order.ID,
+		Type:       models.OrderEventItemAdjustment,
+		ActorID:    &actorID,
+		FromStatus: order.Status,
+		ToStatus:   order.Status,
+		Reason:     change,
+	})
+}
 
-	// Restore inventory when cancelling
-	if len(order.Cart.Items) > 0 {
-		for _, cartItem := range order.Cart.Items {
-			item, err := s.itemRepo.FindByID(cartItem.ItemID)
+// ExpireNoShows moves up to limit ready pickup orders that were not collected
+// within their pantry's threshold to expired, restoring their inventory and
+// counting a no-show against the client. Orders whose workflow has no
+// ready → expired transition are left alone. It returns the number expired.
+func (s *OrderService) ExpireNoShows(limit int) (int, error) {
+	expired := 0
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		orderRepo := s.orderRepo.WithTx(tx)
+		candidates, err := orderRepo.FindNoShowsForUpdate(time.Now(), limit)
+		if err != nil {
+			return err
+		}
+
+		for _, candidate := range candidates {
+			order, err := orderRepo.FindByID(candidate.ID)
+			if err != nil {
+				return err
+			}
+
+			workflow, err := s.workflowRepo.WithTx(tx).FindForPantry(order.PantryID, order.FulfillmentType)
 			if err != nil {
-				continue // Skip if item not found
+				return err
+			}
+			transition := workflow.FindTransition(order.Status, models.OrderStatusExpired)
+			if transition == nil {
+				continue
 			}
-			item.Quantity += cartItem.Quantity
-			s.itemRepo.Update(item)
+
+			reason := fmt.Sprintf("not picked up within %d hours", order.Pantry.NoShowExpiryHours)
+			if err := s.applyTransition(tx, workflow, order, transition, nil, reason); err != nil {
+				return err
+			}
+			if !order.IsWalkIn() {
+				if err := s.userRepo.WithTx(tx).IncrementNoShowCount(*order.UserID); err != nil {
+					return err
+				}
+			}
+			expired++
 		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
-
-	order.Status = models.OrderStatusCancelled
-	return s.orderRepo.Update(order)
+	return expired, nil
 }
 
-// isValidStatusTransition checks if a status transition is valid
-func isValidStatusTransition(from, to models.OrderStatus) bool {
-	validTransitions := map[models.OrderStatus][]models.OrderStatus{
-		models.OrderStatusPending: {
-			models.OrderStatusPreparing,
-			models.OrderStatusCancelled,
-		},
-		models.OrderStatusPreparing: {
-			models.OrderStatusReady,
-			models.OrderStatusCancelled,
-		},
-		models.OrderStatusReady: {
-			models.OrderStatusPickedUp,
-			models.OrderStatusCancelled,
-		},
-		models.OrderStatusPickedUp: {}, // Final state
-		models.OrderStatusCancelled: {}, // Final state
+// applyTransition performs a workflow transition on a locked order: it restores
+// inventory and frees the pickup slot when the order is abandoned, stamps the
+// configured timestamp, invalidates the pickup code once the order is closed,
+// saves the order and records the event
+func (s *OrderService) applyTransition(tx *gorm.DB, workflow *models.OrderWorkflow, order *models.Order, transition *models.WorkflowTransition, actorID *uuid.UUID, reason string) error {
+	if transition.RestoresInventory {
+		ref := models.StockMovement{ActorID: actorID, OrderID: &order.ID, Reason: reason}
+		for _, cartItem := range order.Cart.Items {
+			if err := s.stockService.Return(tx, cartItem.ItemID, cartItem.Quantity, ref); err != nil {
+				return err
+			}
+		}
+
+		if err := releaseSlot(s.slotRepo.WithTx(tx), order); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	if transition.StampField != "" {
+		order.SetTimestamp(transition.StampField, now)
+	}
+	if workflow.IsFinal(transition.ToStatus) && order.HasActivePickupCode() {
+		order.PickupCodeInvalidatedAt = &now
 	}
 
-	allowedTransitions, exists := validTransitions[from]
-	if !exists {
-		return false
+	fromStatus := order.Status
+	order.Status = transition.ToStatus
+	if err := s.orderRepo.WithTx(tx).Update(order); err != nil {
+		return err
 	}
 
-	for _, allowed := range allowedTransitions {
-		if allowed == to {
-			return true
-		}
+	eventType := models.OrderEventStatusChange
+	if transition.ToStatus == models.OrderStatusCancelled {
+		eventType = models.OrderEventCancellation
+	}
+	err := s.eventRepo.WithTx(tx).Create(&models.OrderEvent{
+		OrderID:    order.ID,
+		Type:       eventType,
+		ActorID:    actorID,
+		FromStatus: fromStatus,
+		ToStatus:   transition.ToStatus,
+		Reason:     reason,
+	})
+	if err != nil {
+		return err
+	}
+	return s.staffService.AutoAssign(tx, order)
+}
+
+// lockOrder locks an order row for the rest of the transaction and loads it with its items
+func lockOrder(orderRepo *repositories.OrderRepository, id uuid.UUID) (*models.Order, error) {
+	if _, err := orderRepo.FindByIDForUpdate(id); err != nil {
+		return nil, err
 	}
+	return orderRepo.FindByID(id)
+}
 
-	return false
+// isValidStatusTransition checks if a status transition is allowed by the workflow
+func isValidStatusTransition(workflow *models.OrderWorkflow, from, to models.OrderStatus) bool {
+	return workflow.FindTransition(from, to) != nil
 }
@@ -2,21 +2,29 @@ package services
 
 import (
 	"errors"
+	"time"
 
 	"github.com/byte4bite/byte4bite/internal/models"
 	"github.com/byte4bite/byte4bite/internal/repositories"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // PantryService handles business logic for pantries
 type PantryService struct {
-	pantryRepo *repositories.PantryRepository
+	pantryRepo  *repositories.PantryRepository
+	cartRepo    *repositories.CartRepository
+	txManager   *repositories.TxManager
+	holdService *StockHoldService
 }
 
 // NewPantryService creates a new pantry service
-func NewPantryService(pantryRepo *repositories.PantryRepository) *PantryService {
+func NewPantryService(pantryRepo *repositories.PantryRepository, cartRepo *repositories.CartRepository, txManager *repositories.TxManager, holdService *StockHoldService) *PantryService {
 	return &PantryService{
-		pantryRepo: pantryRepo,
+		pantryRepo:  pantryRepo,
+		cartRepo:    cartRepo,
+		txManager:   txManager,
+		holdService: holdService,
 	}
 }
 
@@ -30,6 +38,14 @@ type CreatePantryRequest struct {
 	ContactEmail string `json:"contact_email" binding:"required,email"`
 	ContactPhone string `json:"contact_phone"`
 	IsActive     bool   `json:"is_active"`
+	Timezone     string `json:"timezone"`
+	SlotRequired bool   `json:"slot_required"`
+	Delivers     bool   `json:"delivers"`
+
+	NoShowExpiryHours *int `json:"no_show_expiry_hours" binding:"omitempty,min=0"` // Defaults to 48
+
+	AssignmentStrategy models.AssignmentStrategy `json:"assignment_strategy"` // Defaults to manual
+	AutoAssignStatus   models.OrderStatus        `json:"auto_assign_status"`  // Defaults to pending
 }
 
 // UpdatePantryRequest represents a request to update a pantry
@@ -42,6 +58,14 @@ type UpdatePantryRequest struct {
 	ContactEmail *string `json:"contact_email"`
 	ContactPhone *string `json:"contact_phone"`
 	IsActive     *bool   `json:"is_active"`
+	Timezone     *string `json:"timezone"`
+	SlotRequired *bool   `json:"slot_required"`
+	Delivers     *bool   `json:"delivers"`
+
+	NoShowExpiryHours *int `json:"no_show_expiry_hours" binding:"omitempty,min=0"`
+
+	AssignmentStrategy *models.AssignmentStrategy `json:"assignment_strategy"`
+	AutoAssignStatus   *models.OrderStatus        `json:"auto_assign_status"`
 }
 
 // GetPantriesRequest represents a request to get pantries
@@ -61,6 +85,14 @@ type GetPantriesResponse struct {
 
 // CreatePantry creates a new pantry
 func (s *PantryService) CreatePantry(req *CreatePantryRequest) (*models.Pantry, error) {
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, errors.New("invalid timezone")
+	}
+
 	pantry := &models.Pantry{
 		Name:         req.Name,
 		Address:      req.Address,
@@ -70,6 +102,26 @@ func (s *PantryService) CreatePantry(req *CreatePantryRequest) (*models.Pantry,
 		ContactEmail: req.ContactEmail,
 		ContactPhone: req.ContactPhone,
 		IsActive:     req.IsActive,
+		Timezone:     timezone,
+		SlotRequired: req.SlotRequired,
+		Delivers:     req.Delivers,
+
+		NoShowExpiryHours: 48,
+
+		AssignmentStrategy: models.AssignmentManual,
+		AutoAssignStatus:   models.OrderStatusPending,
+	}
+	if req.NoShowExpiryHours != nil {
+		pantry.NoShowExpiryHours = *req.NoShowExpiryHours
+	}
+	if req.AssignmentStrategy != "" {
+		pantry.AssignmentStrategy = req.AssignmentStrategy
+	}
+	if req.AutoAssignStatus != "" {
+		pantry.AutoAssignStatus = req.AutoAssignStatus
+	}
+	if err := validateAssignment(pantry); err != nil {
+		return nil, err
 	}
 
 	if err := s.pantryRepo.Create(pantry); err != nil {
@@ -150,8 +202,32 @@ func (s *PantryService) UpdatePantry(id uuid.UUID, req *UpdatePantryRequest) (*m
 	if req.IsActive != nil {
 		pantry.IsActive = *req.IsActive
 	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			return nil, errors.New("invalid timezone")
+		}
+		pantry.Timezone = *req.Timezone
+	}
+	if req.SlotRequired != nil {
+		pantry.SlotRequired = *req.SlotRequired
+	}
+	if req.Delivers != nil {
+		pantry.Delivers = *req.Delivers
+	}
+	if req.NoShowExpiryHours != nil {
+		pantry.NoShowExpiryHours = *req.NoShowExpiryHours
+	}
+	if req.AssignmentStrategy != nil {
+		pantry.AssignmentStrategy = *req.AssignmentStrategy
+	}
+	if req.AutoAssignStatus != nil {
+		pantry.AutoAssignStatus = *req.AutoAssignStatus
+	}
+	if err := validateAssignment(pantry); err != nil {
+		return nil, err
+	}
 
-	if err := s.pantryRepo.Update(pantry); err != nil {
+	if err := s.save(pantry); err != nil {
 		return nil, err
 	}
 
@@ -201,9 +277,41 @@ func (s *PantryService) TogglePantryStatus(id uuid.UUID) (*models.Pantry, error)
 	}
 
 	pantry.IsActive = !pantry.IsActive
-	if err := s.pantryRepo.Update(pantry); err != nil {
+	if err := s.save(pantry); err != nil {
 		return nil, err
 	}
 
 	return pantry, nil
 }
+
+// save updates a pantry. When the pantry is inactive, its clients' active
+// carts are cancelled and their stock holds released in the same transaction.
+func (s *PantryService) save(pantry *models.Pantry) error {
+	return s.txManager.Transaction(func(tx *gorm.DB) error {
+		if err := s.pantryRepo.WithTx(tx).Update(pantry); err != nil {
+			return err
+		}
+		if pantry.IsActive {
+			return nil
+		}
+		if err := s.holdService.ReleasePantry(tx, pantry.ID); err != nil {
+			return err
+		}
+		return s.cartRepo.WithTx(tx).CancelActiveByPantry(pantry.ID)
+	})
+}
+
+// validateAssignment checks a pantry's automatic staff assignment settings
+func validateAssignment(pantry *models.Pantry) error {
+	switch pantry.AssignmentStrategy {
+	case models.AssignmentManual, models.AssignmentRoundRobin, models.AssignmentLeastOpen:
+	default:
+		return errors.New("assignment_strategy must be manual, round_robin or least_open")
+	}
+	switch pantry.AutoAssignStatus {
+	case models.OrderStatusPending, models.OrderStatusPreparing:
+	default:
+		return errors.New("auto_assign_status must be pending or preparing")
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/pdf"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/google/uuid"
+)
+
+// PickListService builds pick lists and packing slips for staff preparing orders
+type PickListService struct {
+	orderRepo  *repositories.OrderRepository
+	pantryRepo *repositories.PantryRepository
+}
+
+// NewPickListService creates a new pick list service
+func NewPickListService(orderRepo *repositories.OrderRepository, pantryRepo *repositories.PantryRepository) *PickListService {
+	return &PickListService{
+		orderRepo:  orderRepo,
+		pantryRepo: pantryRepo,
+	}
+}
+
+// PickList aggregates the items of several orders for a single warehouse walk
+type PickList struct {
+	PantryID    uuid.UUID          `json:"pantry_id"`
+	PantryName  string             `json:"pantry_name"`
+	Status      models.OrderStatus `json:"status"`
+	Date        string             `json:"date,omitempty"`
+	OrderIDs    []uuid.UUID        `json:"order_ids"`
+	OrderCount  int                `json:"order_count"`
+	TotalItems  int                `json:"total_items"`
+	Categories  []PickListCategory `json:"categories"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+// PickListCategory groups the pick list items of one category
+type PickListCategory struct {
+	CategoryID uuid.UUID      `json:"category_id"`
+	Name       string         `json:"name"`
+	Total      int            `json:"total"`
+	Items      []PickListItem `json:"items"`
+}
+
+// PickListItem is the total quantity of one item across the selected orders
+type PickListItem struct {
+	ItemID     uuid.UUID `json:"item_id"`
+	Name       string    `json:"name"`
+	Unit       string    `json:"unit"`
+	Quantity   int       `json:"quantity"`
+	OrderCount int       `json:"order_count"`
+}
+
+// PackingSlip lists the contents of a single order
+type PackingSlip struct {
+	OrderID         uuid.UUID              `json:"order_id"`
+	Status          models.OrderStatus     `json:"status"`
+	PantryName      string                 `json:"pantry_name"`
+	ClientName      string                 `json:"client_name"`
+	ClientPhone     string                 `json:"client_phone"`
+	FulfillmentType models.FulfillmentType `json:"fulfillment_type"`
+	ScheduledFor    string                 `json:"scheduled_for,omitempty"`
+	DeliveryAddress string                 `json:"delivery_address,omitempty"`
+	Instructions    string                 `json:"instructions,omitempty"`
+	Notes           string                 `json:"notes,omitempty"`
+	SubmittedAt     time.Time              `json:"submitted_at"`
+	TotalItems      int                    `json:"total_items"`
+	Lines           []PackingSlipLine      `json:"lines"`
+}
+
+// PackingSlipLine is one item of a packing slip
+type PackingSlipLine struct {
+	Category       string `json:"category"`
+	Item           string `json:"item"`
+	Unit           string `json:"unit"`
+	Quantity       int    `json:"quantity"`
+	Requested      int    `json:"requested"`
+	SubstitutedFor string `json:"substituted_for,omitempty"`
+}
+
+// GetPickList aggregates the items of a pantry's orders in the given status
+// (preparing by default) by category and item. When date (YYYY-MM-DD, pantry
+// time) is set, only orders scheduled for that day are included.
+func (s *PickListService) GetPickList(pantryID uuid.UUID, status models.OrderStatus, date string) (*PickList, error) {
+	pantry, err := s.pantryRepo.FindByID(pantryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == "" {
+		status = models.OrderStatusPreparing
+	}
+
+	var from, to *time.Time
+	if date != "" {
+		day, err := time.ParseInLocation("2006-01-02", date, pantry.Location())
+		if err != nil {
+			return nil, errors.New("date must be formatted as YYYY-MM-DD")
+		}
+		next := day.AddDate(0, 0, 1)
+		from, to = &day, &next
+	}
+
+	orders, err := s.orderRepo.FindForPickList(pantryID, status, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &PickList{
+		PantryID:    pantry.ID,
+		PantryName:  pantry.Name,
+		Status:      status,
+		Date:        date,
+		OrderIDs:    []uuid.UUID{},
+		OrderCount:  len(orders),
+		Categories:  []PickListCategory{},
+		GeneratedAt: time.Now().In(pantry.Location()),
+	}
+
+	categories := make(map[uuid.UUID]*PickListCategory)
+	items := make(map[uuid.UUID]*PickListItem)
+	itemCategory := make(map[uuid.UUID]uuid.UUID)
+
+	for _, order := range orders {
+		list.OrderIDs = append(list.OrderIDs, order.ID)
+		for _, cartItem := range order.Cart.Items {
+			// Lines removed by staff stay on the order with nothing to pick
+			if cartItem.Quantity == 0 {
+				continue
+			}
+			item := cartItem.Item
+			if _, ok := categories[item.CategoryID]; !ok {
+				categories[item.CategoryID] = &PickListCategory{CategoryID: item.CategoryID, Name: item.Category.Name}
+			}
+			if _, ok := items[item.ID]; !ok {
+				items[item.ID] = &PickListItem{ItemID: item.ID, Name: item.Name, Unit: item.Unit}
+				itemCategory[item.ID] = item.CategoryID
+			}
+
+			items[item.ID].Quantity += cartItem.Quantity
+			items[item.ID].OrderCount++
+			categories[item.CategoryID].Total += cartItem.Quantity
+			list.TotalItems += cartItem.Quantity
+		}
+	}
+
+	for itemID, item := range items {
+		category := categories[itemCategory[itemID]]
+		category.Items = append(category.Items, *item)
+	}
+	for _, category := range categories {
+		sort.Slice(category.Items, func(i, j int) bool {
+			return category.Items[i].Name < category.Items[j].Name
+		})
+		list.Categories = append(list.Categories, *category)
+	}
+	sort.Slice(list.Categories, func(i, j int) bool {
+		return list.Categories[i].Name < list.Categories[j].Name
+	})
+
+	return list, nil
+}
+
+// GetPackingSlip builds the packing slip of an order
+func (s *PickListService) GetPackingSlip(orderID uuid.UUID) (*PackingSlip, error) {
+	order, err := s.orderRepo.FindByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	loc := order.Pantry.Location()
+
+	slip := &PackingSlip{
+		OrderID:         order.ID,
+		Status:          order.Status,
+		PantryName:      order.Pantry.Name,
+		ClientName:      order.ClientName(),
+		ClientPhone:     order.ClientPhone(),
+		FulfillmentType: order.FulfillmentType,
+		Notes:           order.Notes,
+		SubmittedAt:     order.SubmittedAt.In(loc),
+		Lines:           []PackingSlipLine{},
+	}
+
+	switch {
+	case order.FulfillmentType == models.FulfillmentDelivery:
+		slip.DeliveryAddress = fmt.Sprintf("%s, %s %s %s", order.DeliveryAddress, order.DeliveryCity, order.DeliveryState, order.DeliveryZipCode)
+		slip.Instructions = order.DeliveryInstructions
+		if order.DeliveryWindowStart != nil && order.DeliveryWindowEnd != nil {
+			slip.ScheduledFor = formatWindow(*order.DeliveryWindowStart, *order.DeliveryWindowEnd, loc)
+		}
+	case order.PickupStart != nil && order.PickupEnd != nil:
+		slip.ScheduledFor = formatWindow(*order.PickupStart, *order.PickupEnd, loc)
+	}
+
+	for _, cartItem := range order.Cart.Items {
+		line := PackingSlipLine{
+			Category:  cartItem.Item.Category.Name,
+			Item:      cartItem.Item.Name,
+			Unit:      cartItem.Item.Unit,
+			Quantity:  cartItem.Quantity,
+			Requested: cartItem.RequestedQuantity,
+		}
+		if cartItem.SubstitutedFor != nil {
+			line.SubstitutedFor = cartItem.SubstitutedFor.Name
+		}
+		slip.Lines = append(slip.Lines, line)
+		slip.TotalItems += cartItem.Quantity
+	}
+	sort.Slice(slip.Lines, func(i, j int) bool {
+		if slip.Lines[i].Category != slip.Lines[j].Category {
+			return slip.Lines[i].Category < slip.Lines[j].Category
+		}
+		return slip.Lines[i].Item < slip.Lines[j].Item
+	})
+
+	return slip, nil
+}
+
+// PickListCSV renders a pick list as CSV, one row per item
+func PickListCSV(list *PickList) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"category", "item", "unit", "quantity", "orders"})
+	for _, category := range list.Categories {
+		for _, item := range category.Items {
+			w.Write([]string{category.Name, item.Name, item.Unit, strconv.Itoa(item.Quantity), strconv.Itoa(item.OrderCount)})
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// PickListPDF renders a pick list as a printable PDF
+func PickListPDF(list *PickList) []byte {
+	doc := pdf.New()
+	doc.Title("Pick list - " + list.PantryName)
+	summary := fmt.Sprintf("Status: %s   Orders: %d   Items: %d   Generated: %s",
+		list.Status, list.OrderCount, list.TotalItems, list.GeneratedAt.Format("Jan 2, 2006 3:04 PM"))
+	if list.Date != "" {
+		summary = "Date: " + list.Date + "   " + summary
+	}
+	doc.Text(summary)
+
+	columns := []float64{20, 330, 400, 460}
+	for _, category := range list.Categories {
+		doc.Space(8)
+		doc.Heading(fmt.Sprintf("%s (%d)", category.Name, category.Total))
+		doc.Columns(columns, "Item", "Unit", "Qty", "Orders")
+		for _, item := range category.Items {
+			doc.Columns(columns, "[  ] "+item.Name, item.Unit, strconv.Itoa(item.Quantity), strconv.Itoa(item.OrderCount))
+		}
+	}
+
+	return doc.Bytes()
+}
+
+// PackingSlipCSV renders a packing slip as CSV, one row per line
+func PackingSlipCSV(slip *PackingSlip) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"order_id", "client", "category", "item", "unit", "quantity", "requested", "substituted_for"})
+	for _, line := range slip.Lines {
+		w.Write([]string{slip.OrderID.String(), slip.ClientName, line.Category, line.Item, line.Unit,
+			strconv.Itoa(line.Quantity), strconv.Itoa(line.Requested), line.SubstitutedFor})
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// PackingSlipPDF renders a packing slip as a printable PDF
+func PackingSlipPDF(slip *PackingSlip) []byte {
+	doc := pdf.New()
+	doc.Title("Packing slip - " + slip.PantryName)
+	doc.Text("Order: " + slip.OrderID.String())
+	doc.Text("Client: " + slip.ClientName + "   " + slip.ClientPhone)
+	doc.Text("Fulfillment: " + string(slip.FulfillmentType))
+	if slip.ScheduledFor != "" {
+		doc.Text("Scheduled: " + slip.ScheduledFor)
+	}
+	if slip.DeliveryAddress != "" {
+		doc.Text("Deliver to: " + slip.DeliveryAddress)
+	}
+	if slip.Instructions != "" {
+		doc.Text("Instructions: " + slip.Instructions)
+	}
+	if slip.Notes != "" {
+		doc.Text("Notes: " + slip.Notes)
+	}
+
+	doc.Space(8)
+	columns := []float64{0, 140, 400, 460}
+	doc.Columns(columns, "Category", "Item", "Unit", "Qty")
+	for _, line := range slip.Lines {
+		item := line.Item
+		if line.SubstitutedFor != "" {
+			item += " (for " + line.SubstitutedFor + ")"
+		}
+		doc.Columns(columns, line.Category, "[  ] "+item, line.Unit, strconv.Itoa(line.Quantity))
+	}
+	doc.Space(8)
+	doc.Heading(fmt.Sprintf("Total items: %d", slip.TotalItems))
+
+	return doc.Bytes()
+}
+
+// formatWindow formats a time window in the given location
+func formatWindow(start, end time.Time, loc *time.Location) string {
+	return start.In(loc).Format("Mon Jan 2, 2006 3:04 PM") + " - " + end.In(loc).Format("3:04 PM")
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrSlotUnavailable is returned when a pickup slot is full or already started
+var ErrSlotUnavailable = errors.New("pickup slot is full or no longer available")
+
+// ErrTemplateNotFound is returned for slot templates that do not exist or
+// belong to another pantry
+var ErrTemplateNotFound = errors.New("slot template not found")
+
+// PickupSlotService handles business logic for pickup slot scheduling
+type PickupSlotService struct {
+	slotRepo     *repositories.PickupSlotRepository
+	pantryRepo   *repositories.PantryRepository
+	orderRepo    *repositories.OrderRepository
+	workflowRepo *repositories.WorkflowRepository
+	txManager    *repositories.TxManager
+}
+
+// NewPickupSlotService creates a new pickup slot service
+func NewPickupSlotService(slotRepo *repositories.PickupSlotRepository, pantryRepo *repositories.PantryRepository, orderRepo *repositories.OrderRepository, workflowRepo *repositories.WorkflowRepository, txManager *repositories.TxManager) *PickupSlotService {
+	return &PickupSlotService{
+		slotRepo:     slotRepo,
+		pantryRepo:   pantryRepo,
+		orderRepo:    orderRepo,
+		workflowRepo: workflowRepo,
+		txManager:    txManager,
+	}
+}
+
+// CreateSlotTemplateRequest represents a request to create a slot template
+type CreateSlotTemplateRequest struct {
+	DayOfWeek   *int   `json:"day_of_week" binding:"required,min=0,max=6"`
+	StartTime   string `json:"start_time" binding:"required"`
+	EndTime     string `json:"end_time" binding:"required"`
+	SlotMinutes int    `json:"slot_minutes" binding:"required,min=5"`
+	Capacity    int    `json:"capacity" binding:"required,min=1"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// UpdateSlotTemplateRequest represents a request to update a slot template
+type UpdateSlotTemplateRequest struct {
+	DayOfWeek   *int    `json:"day_of_week" binding:"omitempty,min=0,max=6"`
+	StartTime   *string `json:"start_time"`
+	EndTime     *string `json:"end_time"`
+	SlotMinutes *int    `json:"slot_minutes" binding:"omitempty,min=5"`
+	Capacity    *int    `json:"capacity" binding:"omitempty,min=1"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// ReschedulePickupRequest represents a request to move an order to another slot
+type ReschedulePickupRequest struct {
+	PickupSlotID uuid.UUID `json:"pickup_slot_id" binding:"required"`
+}
+
+// AvailableSlot is a pickup slot together with its remaining capacity
+type AvailableSlot struct {
+	models.PickupSlot
+	Remaining int `json:"remaining"`
+}
+
+// CreateTemplate creates a new slot template for a pantry
+func (s *PickupSlotService) CreateTemplate(pantryID uuid.UUID, req *CreateSlotTemplateRequest) (*models.PickupSlotTemplate, error) {
+	if _, err := s.pantryRepo.FindByID(pantryID); err != nil {
+		return nil, err
+	}
+
+	template := &models.PickupSlotTemplate{
+		PantryID:    pantryID,
+		DayOfWeek:   *req.DayOfWeek,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		SlotMinutes: req.SlotMinutes,
+		Capacity:    req.Capacity,
+		IsActive:    true,
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+
+	if err := validateSlotTemplate(template); err != nil {
+		return nil, err
+	}
+
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		slotRepo := s.slotRepo.WithTx(tx)
+		if err := s.pantryRepo.WithTx(tx).LockByID(pantryID); err != nil {
+			return err
+		}
+		if err := checkTemplateOverlap(slotRepo, template); err != nil {
+			return err
+		}
+		return slotRepo.CreateTemplate(template)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// GetTemplates retrieves all slot templates for a pantry
+func (s *PickupSlotService) GetTemplates(pantryID uuid.UUID) ([]models.PickupSlotTemplate, error) {
+	return s.slotRepo.FindTemplatesByPantryID(pantryID)
+}
+
+// UpdateTemplate updates a slot template of a pantry. Slots already generated
+// for a day keep their original capacity; deactivating the template retires
+// its future slots.
+func (s *PickupSlotService) UpdateTemplate(pantryID, id uuid.UUID, req *UpdateSlotTemplateRequest) (*models.PickupSlotTemplate, error) {
+	template, err := s.findTemplate(pantryID, id)
+	if err != nil {
+		return nil, err
+	}
+	wasActive := template.IsActive
+
+	if req.DayOfWeek != nil {
+		template.DayOfWeek = *req.DayOfWeek
+	}
+	if req.StartTime != nil {
+		template.StartTime = *req.StartTime
+	}
+	if req.EndTime != nil {
+		template.EndTime = *req.EndTime
+	}
+	if req.SlotMinutes != nil {
+		template.SlotMinutes = *req.SlotMinutes
+	}
+	if req.Capacity != nil {
+		template.Capacity = *req.Capacity
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+
+	if err := validateSlotTemplate(template); err != nil {
+		return nil, err
+	}
+
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		slotRepo := s.slotRepo.WithTx(tx)
+		if err := s.pantryRepo.WithTx(tx).LockByID(pantryID); err != nil {
+			return err
+		}
+		if err := checkTemplateOverlap(slotRepo, template); err != nil {
+			return err
+		}
+		if err := slotRepo.UpdateTemplate(template); err != nil {
+			return err
+		}
+		if wasActive && !template.IsActive {
+			return slotRepo.RetireTemplateSlots(template.ID, time.Now())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// DeleteTemplate deletes a slot template of a pantry and retires its future
+// slots. Orders already booked into them keep their bookings.
+func (s *PickupSlotService) DeleteTemplate(pantryID, id uuid.UUID) error {
+	if _, err := s.findTemplate(pantryID, id); err != nil {
+		return err
+	}
+	return s.txManager.Transaction(func(tx *gorm.DB) error {
+		slotRepo := s.slotRepo.WithTx(tx)
+		if err := slotRepo.RetireTemplateSlots(id, time.Now()); err != nil {
+			return err
+		}
+		return slotRepo.DeleteTemplate(id)
+	})
+}
+
+// findTemplate finds a slot template, treating templates of other pantries as missing
+func (s *PickupSlotService) findTemplate(pantryID, id uuid.UUID) (*models.PickupSlotTemplate, error) {
+	template, err := s.slotRepo.FindTemplateByID(id)
+	if err != nil || template.PantryID != pantryID {
+		return nil, ErrTemplateNotFound
+	}
+	return template, nil
+}
+
+// BookingWindowDays is how many days ahead pickup slots can be viewed and booked
+const BookingWindowDays = 30
+
+// GetSlotsForDate returns the pickup slots of a pantry for a day (YYYY-MM-DD in
+// the pantry's time zone). Days from today through the booking window get
+// their slots generated from the active templates on first use; past days are
+// only read and later days are rejected.
+func (s *PickupSlotService) GetSlotsForDate(pantryID uuid.UUID, date string) ([]AvailableSlot, error) {
+	pantry, err := s.pantryRepo.FindByID(pantryID)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", date, pantry.Location())
+	if err != nil {
+		return nil, errors.New("date must be formatted as YYYY-MM-DD")
+	}
+
+	now := time.Now().In(pantry.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, pantry.Location())
+	if day.After(today.AddDate(0, 0, BookingWindowDays)) {
+		return nil, fmt.Errorf("pickup slots can only be booked up to %d days ahead", BookingWindowDays)
+	}
+
+	if !day.Before(today) {
+		templates, err := s.slotRepo.FindActiveTemplates(pantryID, day.Weekday())
+		if err != nil {
+			return nil, err
+		}
+
+		var generated []models.PickupSlot
+		for _, template := range templates {
+			generated = append(generated, slotsFromTemplate(template, day)...)
+		}
+		if err := s.slotRepo.EnsureSlots(generated); err != nil {
+			return nil, err
+		}
+	}
+
+	slots, err := s.slotRepo.FindSlotsInRange(pantryID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]AvailableSlot, 0, len(slots))
+	for _, slot := range slots {
+		result = append(result, AvailableSlot{PickupSlot: slot, Remaining: slot.Remaining()})
+	}
+	return result, nil
+}
+
+// Reschedule moves an order's pickup booking to another slot, freeing the old one
+func (s *PickupSlotService) Reschedule(orderID, userID uuid.UUID, isAdmin bool, slotID uuid.UUID) (*models.Order, error) {
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		orderRepo := s.orderRepo.WithTx(tx)
+		slotRepo := s.slotRepo.WithTx(tx)
+
+		order, err := orderRepo.FindByIDForUpdate(orderID)
+		if err != nil {
+			return err
+		}
+		if !isAdmin && !order.IsOwnedBy(userID) {
+			return errors.New("unauthorized to modify this order")
+		}
+		if err := s.checkOrderOpen(order); err != nil {
+			return err
+		}
+		if order.PickupSlotID != nil && *order.PickupSlotID == slotID {
+			return nil
+		}
+
+		if err := releaseSlot(slotRepo, order); err != nil {
+			return err
+		}
+		if err := bookSlot(slotRepo, order, slotID); err != nil {
+			return err
+		}
+		return orderRepo.Update(order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.orderRepo.FindByID(orderID)
+}
+
+// CancelBooking frees an order's pickup slot without cancelling the order
+func (s *PickupSlotService) CancelBooking(orderID, userID uuid.UUID, isAdmin bool) (*models.Order, error) {
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		orderRepo := s.orderRepo.WithTx(tx)
+
+		order, err := orderRepo.FindByIDForUpdate(orderID)
+		if err != nil {
+			return err
+		}
+		if !isAdmin && !order.IsOwnedBy(userID) {
+			return errors.New("unauthorized to modify this order")
+		}
+		if order.PickupSlotID == nil {
+			return errors.New("order has no pickup slot booked")
+		}
+		if err := s.checkOrderOpen(order); err != nil {
+			return err
+		}
+
+		pantry, err := s.pantryRepo.FindByID(order.PantryID)
+		if err != nil {
+			return err
+		}
+		if pantry.SlotRequired {
+			return errors.New("this pantry requires a pickup slot; reschedule instead")
+		}
+
+		if err := releaseSlot(s.slotRepo.WithTx(tx), order); err != nil {
+			return err
+		}
+		return orderRepo.Update(order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.orderRepo.FindByID(orderID)
+}
+
+// bookSlot reserves a place in a pickup slot and records the booked window on the order
+func bookSlot(slotRepo *repositories.PickupSlotRepository, order *models.Order, slotID uuid.UUID) error {
+	slot, err := slotRepo.FindSlotByID(slotID)
+	if err != nil {
+		return err
+	}
+	if slot.PantryID != order.PantryID {
+		return errors.New("pickup slot belongs to a different pantry")
+	}
+
+	reserved, err := slotRepo.Reserve(slot.ID)
+	if err != nil {
+		return err
+	}
+	if !reserved {
+		return ErrSlotUnavailable
+	}
+
+	order.PickupSlotID = &slot.ID
+	order.PickupStart = &slot.StartsAt
+	order.PickupEnd = &slot.EndsAt
+	return nil
+}
+
+// releaseSlot frees the order's booked pickup slot, if any
+func releaseSlot(slotRepo *repositories.PickupSlotRepository, order *models.Order) error {
+	if order.PickupSlotID == nil {
+		return nil
+	}
+	if err := slotRepo.Release(*order.PickupSlotID); err != nil {
+		return err
+	}
+	order.PickupSlotID = nil
+	order.PickupStart = nil
+	order.PickupEnd = nil
+	return nil
+}
+
+// checkOrderOpen ensures the order has not reached a final state of its workflow
+func (s *PickupSlotService) checkOrderOpen(order *models.Order) error {
+	workflow, err := s.workflowRepo.FindForPantry(order.PantryID, order.FulfillmentType)
+	if err != nil {
+		return err
+	}
+	if workflow.IsFinal(order.Status) {
+		return errors.New("pickup can only be changed for open orders")
+	}
+	return nil
+}
+
+// slotsFromTemplate splits a template's window into consecutive slots on the given day
+func slotsFromTemplate(template models.PickupSlotTemplate, day time.Time) []models.PickupSlot {
+	start, _ := parseClock(template.StartTime)
+	end, _ := parseClock(template.EndTime)
+
+	var slots []models.PickupSlot
+	for minute := start; minute+template.SlotMinutes <= end; minute += template.SlotMinutes {
+		templateID := template.ID
+		slots = append(slots, models.PickupSlot{
+			PantryID:   template.PantryID,
+			TemplateID: &templateID,
+			StartsAt:   time.Date(day.Year(), day.Month(), day.Day(), 0, minute, 0, 0, day.Location()),
+			EndsAt:     time.Date(day.Year(), day.Month(), day.Day(), 0, minute+template.SlotMinutes, 0, 0, day.Location()),
+			Capacity:   template.Capacity,
+		})
+	}
+	return slots
+}
+
+// validateSlotTemplate checks that a template describes a usable window
+func validateSlotTemplate(template *models.PickupSlotTemplate) error {
+	start, err := parseClock(template.StartTime)
+	if err != nil {
+		return errors.New("start_time must be formatted as HH:MM")
+	}
+	end, err := parseClock(template.EndTime)
+	if err != nil {
+		return errors.New("end_time must be formatted as HH:MM")
+	}
+	if end <= start {
+		return errors.New("end_time must be after start_time")
+	}
+	if template.SlotMinutes > end-start {
+		return errors.New("slot_minutes is longer than the template window")
+	}
+	return nil
+}
+
+// checkTemplateOverlap rejects an active template whose window overlaps another
+// active template of the pantry on the same weekday. Slots are unique per
+// start time, so one of two overlapping templates would silently lose its
+// slots. The caller holds the pantry lock so concurrent saves cannot both pass.
+func checkTemplateOverlap(slotRepo *repositories.PickupSlotRepository, template *models.PickupSlotTemplate) error {
+	if !template.IsActive {
+		return nil
+	}
+	others, err := slotRepo.FindActiveTemplates(template.PantryID, time.Weekday(template.DayOfWeek))
+	if err != nil {
+		return err
+	}
+
+	start, _ := parseClock(template.StartTime)
+	end, _ := parseClock(template.EndTime)
+	for _, other := range others {
+		if other.ID == template.ID {
+			continue
+		}
+		otherStart, _ := parseClock(other.StartTime)
+		otherEnd, _ := parseClock(other.EndTime)
+		if start < otherEnd && otherStart < end {
+			return fmt.Errorf("template overlaps the %s-%s template on the same day", other.StartTime, other.EndTime)
+		}
+	}
+	return nil
+}
+
+// parseClock converts an "HH:MM" string into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/auth"
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Pickup codes avoid characters that are easily confused when read aloud or
+// typed, such as 0/O and 1/I/L
+const (
+	pickupCodeAlphabet = "ACDEFGHJKMNPQRTUVWXY346789"
+	pickupCodeLength   = 6
+)
+
+// Failed verifications allowed per staff member, and per pantry across all of
+// its staff, before further attempts are blocked
+const (
+	maxFailedVerifications       = 10
+	maxFailedPantryVerifications = 50
+	failedVerificationWindow     = 15 * time.Minute
+)
+
+var (
+	// ErrInvalidPickupCode is returned when a pickup code or token matches no open order
+	ErrInvalidPickupCode = errors.New("invalid or expired pickup code")
+	// ErrTooManyAttempts is returned once a staff member or pantry made too many failed verifications
+	ErrTooManyAttempts = errors.New("too many failed verification attempts, try again later")
+)
+
+// PickupVerificationService handles pickup codes and QR check-in. Failed
+// attempts are counted in memory, so the limits apply per process and reset on
+// restart; running several instances multiplies the attempts allowed.
+type PickupVerificationService struct {
+	orderRepo     *repositories.OrderRepository
+	orderService  *OrderService
+	tokenService  *auth.PickupTokenService
+	workflowRepo  *repositories.WorkflowRepository
+	limiter       *attemptLimiter
+	pantryLimiter *attemptLimiter
+}
+
+// NewPickupVerificationService creates a new pickup verification service
+func NewPickupVerificationService(orderRepo *repositories.OrderRepository, orderService *OrderService, tokenService *auth.PickupTokenService, workflowRepo *repositories.WorkflowRepository) *PickupVerificationService {
+	return &PickupVerificationService{
+		orderRepo:     orderRepo,
+		orderService:  orderService,
+		tokenService:  tokenService,
+		workflowRepo:  workflowRepo,
+		limiter:       newAttemptLimiter(maxFailedVerifications, failedVerificationWindow),
+		pantryLimiter: newAttemptLimiter(maxFailedPantryVerifications, failedVerificationWindow),
+	}
+}
+
+// VerifyPickupRequest carries either a pickup code with its pantry or a QR token
+type VerifyPickupRequest struct {
+	PantryID *uuid.UUID `json:"pantry_id"` // Required with code
+	Code     string     `json:"code"`
+	Token    string     `json:"token"`
+}
+
+// PickupPass is what a client shows at pickup: the code and its QR payload
+type PickupPass struct {
+	OrderID uuid.UUID `json:"order_id"`
+	Code    string    `json:"code"`
+	Token   string    `json:"token"` // Encode as a QR code
+}
+
+// GetPickupPass returns the pickup code and QR token of an open pickup order
+func (s *PickupVerificationService) GetPickupPass(orderID, userID uuid.UUID, isAdmin bool) (*PickupPass, error) {
+	order, err := s.orderService.GetOrder(orderID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if !order.HasActivePickupCode() {
+		return nil, errors.New("order has no active pickup code")
+	}
+
+	return &PickupPass{
+		OrderID: order.ID,
+		Code:    order.PickupCode,
+		Token:   s.tokenService.Sign(order.ID, order.PickupCode),
+	}, nil
+}
+
+// Verify finds the order matching a pickup code or QR token and moves it to the
+// final state its workflow stamps as picked up. Failed lookups count against the staff member's rate limit and, for
+// pickup codes, against the pantry's.
+func (s *PickupVerificationService) Verify(actorID uuid.UUID, req *VerifyPickupRequest) (*models.Order, error) {
+	key := actorID.String()
+	if !s.limiter.Allow(key) {
+		return nil, ErrTooManyAttempts
+	}
+	// Tokens are signed, so only code guesses are limited per pantry
+	var pantryKey string
+	if req.Token == "" && req.PantryID != nil {
+		pantryKey = req.PantryID.String()
+		if !s.pantryLimiter.Allow(pantryKey) {
+			return nil, ErrTooManyAttempts
+		}
+	}
+
+	order, err := s.resolve(req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPickupCode) {
+			s.limiter.Fail(key)
+			if pantryKey != "" {
+				s.pantryLimiter.Fail(pantryKey)
+			}
+		}
+		return nil, err
+	}
+
+	// The pantry's workflow decides which final state marks the order collected
+	workflow, err := s.workflowRepo.FindForPantry(order.PantryID, order.FulfillmentType)
+	if err != nil {
+		return nil, err
+	}
+	transition := workflow.FindStampingTransition(order.Status, models.OrderTimestampPickedUpAt)
+	if transition == nil {
+		return nil, errors.New("order cannot be picked up in its current status")
+	}
+
+	if err := s.orderService.UpdateOrderStatus(order.ID, actorID, transition.ToStatus, "verified with pickup code"); err != nil {
+		return nil, err
+	}
+
+	return s.orderRepo.FindByID(order.ID)
+}
+
+// resolve finds the order a verification request refers to
+func (s *PickupVerificationService) resolve(req *VerifyPickupRequest) (*models.Order, error) {
+	if req.Token != "" {
+		orderID, code, err := s.tokenService.Verify(req.Token)
+		if err != nil {
+			return nil, ErrInvalidPickupCode
+		}
+		order, err := s.orderRepo.FindByID(orderID)
+		if err != nil || order.PickupCode != code {
+			return nil, ErrInvalidPickupCode
+		}
+		// A correctly signed token for a used code is not a guess
+		if !order.HasActivePickupCode() {
+			return nil, errors.New("pickup code has already been used or the order was closed")
+		}
+		return order, nil
+	}
+
+	if req.Code == "" {
+		return nil, errors.New("code or token is required")
+	}
+	if req.PantryID == nil {
+		return nil, errors.New("pantry_id is required with a pickup code")
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	order, err := s.orderRepo.FindByPickupCode(*req.PantryID, code)
+	if err != nil {
+		return nil, ErrInvalidPickupCode
+	}
+	return order, nil
+}
+
+// createWithPickupCode creates a pickup order with a new random pickup code.
+// The database rejects a code already active at the pantry; the insert then
+// rolls back to a savepoint and is retried with another code.
+func createWithPickupCode(tx *gorm.DB, orderRepo *repositories.OrderRepository, order *models.Order) error {
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := randomPickupCode()
+		if err != nil {
+			return err
+		}
+		order.PickupCode = code
+
+		err = tx.Transaction(func(savepoint *gorm.DB) error {
+			return orderRepo.WithTx(savepoint).Create(order)
+		})
+		if !repositories.IsPickupCodeConflict(err) {
+			return err
+		}
+	}
+	return errors.New("failed to generate a unique pickup code")
+}
+
+// randomPickupCode returns a random code drawn from pickupCodeAlphabet
+func randomPickupCode() (string, error) {
+	alphabetSize := big.NewInt(int64(len(pickupCodeAlphabet)))
+	code := make([]byte, pickupCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		code[i] = pickupCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/google/uuid"
+)
+
+// ReportService builds distribution reports
+type ReportService struct {
+	orderRepo *repositories.OrderRepository
+}
+
+// NewReportService creates a new report service
+func NewReportService(orderRepo *repositories.OrderRepository) *ReportService {
+	return &ReportService{
+		orderRepo: orderRepo,
+	}
+}
+
+// DistributionReport compares what clients requested with what was handed out
+type DistributionReport struct {
+	PantryID         *uuid.UUID                      `json:"pantry_id,omitempty"`
+	StartDate        *time.Time                      `json:"start_date,omitempty"`
+	EndDate          *time.Time                      `json:"end_date,omitempty"`
+	OrderCount       int64                           `json:"order_count"`
+	WalkInOrders     int64                           `json:"walk_in_orders"`
+	AssistedOrders   int64                           `json:"assisted_orders"`
+	HouseholdsServed int64                           `json:"households_served"`
+	TotalRequested   int                             `json:"total_requested"`
+	TotalDistributed int                             `json:"total_distributed"`
+	Items            []repositories.ItemDistribution `json:"items"`
+}
+
+// GetDistributionReport sums requested and distributed quantities per item over
+// orders completed in [startDate, endDate). An order is completed once it
+// reaches a final state of its workflow other than by being abandoned.
+func (s *ReportService) GetDistributionReport(pantryID *uuid.UUID, startDate, endDate *time.Time) (*DistributionReport, error) {
+	items, err := s.orderRepo.DistributionByItem(pantryID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.orderRepo.CountServed(pantryID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DistributionReport{
+		PantryID:         pantryID,
+		StartDate:        startDate,
+		EndDate:          endDate,
+		OrderCount:       counts.Orders,
+		WalkInOrders:     counts.WalkInOrders,
+		AssistedOrders:   counts.AssistedOrders,
+		HouseholdsServed: counts.HouseholdsServed,
+		Items:            items,
+	}
+	if report.Items == nil {
+		report.Items = []repositories.ItemDistribution{}
+	}
+	for _, item := range items {
+		report.TotalRequested += item.Requested
+		report.TotalDistributed += item.Distributed
+	}
+
+	return report, nil
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StaffService assigns orders to pantry staff and reports their workload
+type StaffService struct {
+	userRepo     *repositories.UserRepository
+	pantryRepo   *repositories.PantryRepository
+	orderRepo    *repositories.OrderRepository
+	eventRepo    *repositories.OrderEventRepository
+	workflowRepo *repositories.WorkflowRepository
+}
+
+// NewStaffService creates a new staff service
+func NewStaffService(userRepo *repositories.UserRepository, pantryRepo *repositories.PantryRepository, orderRepo *repositories.OrderRepository, eventRepo *repositories.OrderEventRepository, workflowRepo *repositories.WorkflowRepository) *StaffService {
+	return &StaffService{
+		userRepo:     userRepo,
+		pantryRepo:   pantryRepo,
+		orderRepo:    orderRepo,
+		eventRepo:    eventRepo,
+		workflowRepo: workflowRepo,
+	}
+}
+
+// StaffWorkload reports the open orders assigned to one staff member
+type StaffWorkload struct {
+	Staff          models.User                `json:"staff"`
+	OpenOrders     int                        `json:"open_orders"`
+	ByStatus       map[models.OrderStatus]int `json:"by_status"`
+	LastAssignedAt *time.Time                 `json:"last_assigned_at"`
+}
+
+// PantryWorkload reports the open orders of a pantry's staff
+type PantryWorkload struct {
+	PantryID           uuid.UUID                 `json:"pantry_id"`
+	AssignmentStrategy models.AssignmentStrategy `json:"assignment_strategy"`
+	Unassigned         int                       `json:"unassigned"`
+	Staff              []StaffWorkload           `json:"staff"`
+}
+
+// ValidateStaff checks that a user is staff at the given pantry
+func (s *StaffService) ValidateStaff(staffID, pantryID uuid.UUID) error {
+	staff, err := s.userRepo.FindByID(staffID)
+	if err != nil {
+		return errors.New("staff member not found")
+	}
+	if staff.Role != models.RoleAdmin || staff.PantryID == nil || *staff.PantryID != pantryID {
+		return errors.New("assignee is not staff at the order's pantry")
+	}
+	return nil
+}
+
+// GetWorkload reports the open orders per staff member of a pantry, including
+// staff without any
+func (s *StaffService) GetWorkload(pantryID uuid.UUID) (*PantryWorkload, error) {
+	pantry, err := s.pantryRepo.FindByID(pantryID)
+	if err != nil {
+		return nil, err
+	}
+
+	workloads, unassigned, err := staffWorkloads(s.userRepo, s.orderRepo, s.eventRepo, s.workflowRepo, pantryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PantryWorkload{
+		PantryID:           pantry.ID,
+		AssignmentStrategy: pantry.AssignmentStrategy,
+		Unassigned:         unassigned,
+		Staff:              workloads,
+	}, nil
+}
+
+// AutoAssign assigns an unassigned order that has just entered its pantry's
+// auto-assignment status to a staff member, following the pantry's strategy.
+// It runs inside the transaction that saved the order; the pantry row is
+// locked so concurrent assignments see each other.
+func (s *StaffService) AutoAssign(tx *gorm.DB, order *models.Order) error {
+	if order.AssignedToID != nil {
+		return nil
+	}
+
+	pantryRepo := s.pantryRepo.WithTx(tx)
+	pantry, err := pantryRepo.FindByID(order.PantryID)
+	if err != nil {
+		return err
+	}
+	if pantry.AssignmentStrategy == "" || pantry.AssignmentStrategy == models.AssignmentManual {
+		return nil
+	}
+	if order.Status != pantry.AutoAssignStatus {
+		return nil
+	}
+	if err := pantryRepo.LockByID(pantry.ID); err != nil {
+		return err
+	}
+
+	workloads, _, err := staffWorkloads(s.userRepo.WithTx(tx), s.orderRepo.WithTx(tx), s.eventRepo.WithTx(tx), s.workflowRepo.WithTx(tx), pantry.ID)
+	if err != nil {
+		return err
+	}
+	if len(workloads) == 0 {
+		// Nobody to assign to; the order waits for manual assignment
+		return nil
+	}
+
+	// Never-assigned staff come first, then the least recently assigned
+	lessRecent := func(a, b StaffWorkload) bool {
+		if a.LastAssignedAt == nil || b.LastAssignedAt == nil {
+			return a.LastAssignedAt == nil && b.LastAssignedAt != nil
+		}
+		return a.LastAssignedAt.Before(*b.LastAssignedAt)
+	}
+	sort.SliceStable(workloads, func(i, j int) bool {
+		if pantry.AssignmentStrategy == models.AssignmentLeastOpen && workloads[i].OpenOrders != workloads[j].OpenOrders {
+			return workloads[i].OpenOrders < workloads[j].OpenOrders
+		}
+		return lessRecent(workloads[i], workloads[j])
+	})
+	staffID := workloads[0].Staff.ID
+
+	if err := s.orderRepo.WithTx(tx).AssignToStaff(order.ID, staffID); err != nil {
+		return err
+	}
+	order.AssignedToID = &staffID
+
+	return s.eventRepo.WithTx(tx).Create(&models.OrderEvent{
+		OrderID:      order.ID,
+		Type:         models.OrderEventAssignment,
+		FromStatus:   order.Status,
+		ToStatus:     order.Status,
+		AssignedToID: &staffID,
+		Reason:       "assigned automatically (" + string(pantry.AssignmentStrategy) + ")",
+	})
+}
+
+// staffWorkloads counts the open orders of each staff member of a pantry and the
+// pantry's unassigned open orders. Staff are returned sorted by name.
+func staffWorkloads(userRepo *repositories.UserRepository, orderRepo *repositories.OrderRepository, eventRepo *repositories.OrderEventRepository, workflowRepo *repositories.WorkflowRepository, pantryID uuid.UUID) ([]StaffWorkload, int, error) {
+	staff, err := userRepo.FindStaffByPantry(pantryID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	closed, err := closedStatuses(workflowRepo, pantryID)
+	if err != nil {
+		return nil, 0, err
+	}
+	counts, err := orderRepo.CountOpenByAssignee(pantryID, closed)
+	if err != nil {
+		return nil, 0, err
+	}
+	times, err := eventRepo.LastAssignmentTimes(pantryID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	workloads := make([]StaffWorkload, len(staff))
+	index := make(map[uuid.UUID]int)
+	for i, member := range staff {
+		workloads[i] = StaffWorkload{Staff: member, ByStatus: map[models.OrderStatus]int{}}
+		index[member.ID] = i
+	}
+
+	unassigned := 0
+	for _, count := range counts {
+		if count.AssignedToID == nil {
+			unassigned += count.Count
+			continue
+		}
+		// Orders held by former staff are not anyone's current workload
+		i, ok := index[*count.AssignedToID]
+		if !ok {
+			continue
+		}
+		workloads[i].OpenOrders += count.Count
+		workloads[i].ByStatus[count.Status] += count.Count
+	}
+	for _, t := range times {
+		if i, ok := index[t.AssignedToID]; ok {
+			lastAssignedAt := t.LastAssignedAt
+			workloads[i].LastAssignedAt = &lastAssignedAt
+		}
+	}
+
+	return workloads, unassigned, nil
+}
+
+// closedStatuses returns the statuses that are final in any of a pantry's workflows
+func closedStatuses(workflowRepo *repositories.WorkflowRepository, pantryID uuid.UUID) ([]models.OrderStatus, error) {
+	var closed []models.OrderStatus
+	seen := make(map[models.OrderStatus]bool)
+	for _, fulfillment := range []models.FulfillmentType{models.FulfillmentPickup, models.FulfillmentDelivery} {
+		workflow, err := workflowRepo.FindForPantry(pantryID, fulfillment)
+		if err != nil {
+			return nil, err
+		}
+		for _, state := range workflow.States {
+			if state.IsFinal && !seen[state.Status] {
+				seen[state.Status] = true
+				closed = append(closed, state.Status)
+			}
+		}
+	}
+	return closed, nil
+}
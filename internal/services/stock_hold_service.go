@@ -0,0 +1,146 @@
+package services
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StockHoldService reserves stock for items in active carts so clients do not
+// lose them to someone else before checking out. Holds expire after a TTL and
+// are released by a background sweep.
+type StockHoldService struct {
+	holdRepo  *repositories.StockHoldRepository
+	itemRepo  *repositories.ItemRepository
+	txManager *repositories.TxManager
+	ttl       time.Duration
+}
+
+// NewStockHoldService creates a new stock hold service
+func NewStockHoldService(holdRepo *repositories.StockHoldRepository, itemRepo *repositories.ItemRepository, txManager *repositories.TxManager, ttl time.Duration) *StockHoldService {
+	return &StockHoldService{
+		holdRepo:  holdRepo,
+		itemRepo:  itemRepo,
+		txManager: txManager,
+		ttl:       ttl,
+	}
+}
+
+// Set makes a cart hold exactly quantity of an item, reserving or releasing the
+// difference to its current hold, and extends the expiry of all the cart's
+// holds. Returns false if there is not enough unheld stock.
+func (s *StockHoldService) Set(tx *gorm.DB, cartID, itemID uuid.UUID, quantity int) (bool, error) {
+	holdRepo := s.holdRepo.WithTx(tx)
+	itemRepo := s.itemRepo.WithTx(tx)
+
+	hold, err := holdRepo.Find(cartID, itemID)
+	if err != nil {
+		return false, err
+	}
+	held := 0
+	if hold != nil {
+		held = hold.Quantity
+	}
+
+	switch delta := quantity - held; {
+	case delta > 0:
+		ok, err := itemRepo.HoldStock(itemID, delta)
+		if err != nil || !ok {
+			return false, err
+		}
+	case delta < 0:
+		if err := itemRepo.ReleaseHold(itemID, -delta); err != nil {
+			return false, err
+		}
+	}
+
+	expiresAt := time.Now().Add(s.ttl)
+	switch {
+	case quantity == 0 && hold != nil:
+		err = holdRepo.Delete(hold.ID)
+	case quantity > 0 && hold == nil:
+		err = holdRepo.Save(&models.StockHold{CartID: cartID, ItemID: itemID, Quantity: quantity, ExpiresAt: expiresAt})
+	case quantity > 0:
+		hold.Quantity = quantity
+		err = holdRepo.Save(hold)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, holdRepo.ExtendCart(cartID, expiresAt)
+}
+
+// CartHolds returns the quantity a cart holds of each item
+func (s *StockHoldService) CartHolds(cartID uuid.UUID) (map[uuid.UUID]int, error) {
+	holds, err := s.holdRepo.ListByCartID(cartID)
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[uuid.UUID]int, len(holds))
+	for _, hold := range holds {
+		held[hold.ItemID] = hold.Quantity
+	}
+	return held, nil
+}
+
+// ReleaseCart releases every hold of a cart
+func (s *StockHoldService) ReleaseCart(tx *gorm.DB, cartID uuid.UUID) error {
+	holds, err := s.holdRepo.WithTx(tx).FindByCartID(cartID)
+	if err != nil {
+		return err
+	}
+	return s.release(tx, holds)
+}
+
+// ReleasePantry releases every hold of the active carts at a pantry
+func (s *StockHoldService) ReleasePantry(tx *gorm.DB, pantryID uuid.UUID) error {
+	holds, err := s.holdRepo.WithTx(tx).FindByPantryID(pantryID)
+	if err != nil {
+		return err
+	}
+	return s.release(tx, holds)
+}
+
+// ExpireHolds releases up to limit expired holds and returns how many were
+// released. Carts keep their items; they are held again when next changed.
+func (s *StockHoldService) ExpireHolds(limit int) (int, error) {
+	released := 0
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		holds, err := s.holdRepo.WithTx(tx).FindExpiredForUpdate(time.Now(), limit)
+		if err != nil {
+			return err
+		}
+		released = len(holds)
+		return s.release(tx, holds)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return released, nil
+}
+
+// release returns the held stock to the items and deletes the holds. Items are
+// updated in a stable order so concurrent releases cannot deadlock.
+func (s *StockHoldService) release(tx *gorm.DB, holds []models.StockHold) error {
+	sort.Slice(holds, func(i, j int) bool {
+		return bytes.Compare(holds[i].ItemID[:], holds[j].ItemID[:]) < 0
+	})
+
+	holdRepo := s.holdRepo.WithTx(tx)
+	itemRepo := s.itemRepo.WithTx(tx)
+	for _, hold := range holds {
+		if err := itemRepo.ReleaseHold(hold.ItemID, hold.Quantity); err != nil {
+			return err
+		}
+		if err := holdRepo.Delete(hold.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StockService changes item stock. It keeps an item's quantity, its lots and
+// the stock movement ledger in step, and allocates stock first expiring first
+// out. Every method except ExpireLots runs inside the caller's transaction.
+type StockService struct {
+	itemRepo     *repositories.ItemRepository
+	lotRepo      *repositories.ItemLotRepository
+	movementRepo *repositories.StockMovementRepository
+	txManager    *repositories.TxManager
+}
+
+// NewStockService creates a new stock service
+func NewStockService(itemRepo *repositories.ItemRepository, lotRepo *repositories.ItemLotRepository, movementRepo *repositories.StockMovementRepository, txManager *repositories.TxManager) *StockService {
+	return &StockService{
+		itemRepo:     itemRepo,
+		lotRepo:      lotRepo,
+		movementRepo: movementRepo,
+		txManager:    txManager,
+	}
+}
+
+// LotDetails describes a lot being received
+type LotDetails struct {
+	ExpiresAt  *time.Time
+	Source     string
+	DonationID *uuid.UUID
+}
+
+// LotAllocation is the quantity taken from or given to one lot
+type LotAllocation struct {
+	Lot      models.ItemLot
+	Quantity int
+}
+
+// errLotsOutOfSync is returned when an item's lots cannot cover stock its total says it has
+var errLotsOutOfSync = errors.New("item lots do not match its quantity")
+
+// Take decrements an item's stock for an order from its earliest expiring
+// unexpired lots and records a distribute movement per lot. ref carries the
+// actor, order and reason. Returns false if the stock was insufficient.
+func (s *StockService) Take(tx *gorm.DB, itemID uuid.UUID, quantity int, ref models.StockMovement) (bool, error) {
+	if quantity <= 0 {
+		return true, nil
+	}
+
+	// Lots past their date must not be handed out even if the sweep has not
+	// flagged them yet
+	if err := s.expireLapsed(tx, &itemID, lapsedBatchSize); err != nil {
+		return false, err
+	}
+
+	itemRepo := s.itemRepo.WithTx(tx)
+	ok, err := itemRepo.DecrementStock(itemID, quantity)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	// Lots flagged by a concurrent sweep may not be counted as expired yet;
+	// treat what they cannot cover as out of stock
+	allocations, err := s.allocate(tx, itemID, quantity, false)
+	if err != nil || allocations == nil {
+		return false, err
+	}
+
+	ref.Type = models.StockDistribute
+	for _, allocation := range allocations {
+		if err := s.lotRepo.WithTx(tx).AdjustQuantity(allocation.Lot.ID, -allocation.Quantity); err != nil {
+			return false, err
+		}
+		if err := s.record(tx, itemID, &allocation.Lot, -allocation.Quantity, ref); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Return gives stock taken for ref's order back to an item, into the lots it
+// was taken from, and records a return movement per lot. Stock the order took
+// before lots were tracked goes into a new lot without a date.
+func (s *StockService) Return(tx *gorm.DB, itemID uuid.UUID, quantity int, ref models.StockMovement) error {
+	if quantity <= 0 {
+		return nil
+	}
+
+	var allocations []LotAllocation
+	if ref.OrderID != nil {
+		balances, err := s.movementRepo.WithTx(tx).OutstandingByLot(*ref.OrderID, itemID)
+		if err != nil {
+			return err
+		}
+		ids := make([]uuid.UUID, len(balances))
+		outstanding := make(map[uuid.UUID]int, len(balances))
+		for i, balance := range balances {
+			ids[i] = balance.LotID
+			outstanding[balance.LotID] = balance.Quantity
+		}
+		lots := []models.ItemLot{}
+		if len(ids) > 0 {
+			if lots, err = s.lotRepo.WithTx(tx).FindByIDsForUpdate(ids); err != nil {
+				return err
+			}
+		}
+
+		// Give back the latest expiring first so the order keeps the oldest stock
+		sort.Slice(lots, func(i, j int) bool {
+			return expiresBefore(lots[j], lots[i])
+		})
+		remaining := quantity
+		for _, lot := range lots {
+			if remaining == 0 {
+				break
+			}
+			n := min(outstanding[lot.ID], remaining)
+			allocations = append(allocations, LotAllocation{Lot: lot, Quantity: n})
+			remaining -= n
+		}
+		quantity = remaining
+	}
+	if quantity > 0 {
+		lot, err := s.newLot(tx, itemID, LotDetails{Source: "return"})
+		if err != nil {
+			return err
+		}
+		allocations = append(allocations, LotAllocation{Lot: *lot, Quantity: quantity})
+	}
+
+	ref.Type = models.StockReturn
+	itemRepo := s.itemRepo.WithTx(tx)
+	for _, allocation := range allocations {
+		if err := itemRepo.RestoreStock(itemID, allocation.Quantity); err != nil {
+			return err
+		}
+		if err := s.creditLot(tx, &allocation.Lot, allocation.Quantity); err != nil {
+			return err
+		}
+		if err := s.record(tx, itemID, &allocation.Lot, allocation.Quantity, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Receive adds stock to an item as a new lot and records a movement of ref's
+// type, receive by default
+func (s *StockService) Receive(tx *gorm.DB, itemID uuid.UUID, quantity int, details LotDetails, ref models.StockMovement) (*models.ItemLot, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+
+	lot, err := s.newLot(tx, itemID, details)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.itemRepo.WithTx(tx).AdjustQuantity(itemID, quantity); err != nil {
+		return nil, err
+	}
+	if err := s.creditLot(tx, lot, quantity); err != nil {
+		return nil, err
+	}
+
+	if ref.Type == "" {
+		ref.Type = models.StockReceive
+	}
+	if ref.DonationID == nil {
+		ref.DonationID = details.DonationID
+	}
+	return lot, s.record(tx, itemID, lot, quantity, ref)
+}
+
+// Remove takes stock out of an item outside of orders, for spoilage,
+// corrections and transfers, and records a movement of ref's type per lot.
+// With lotID set only that lot is drawn from; otherwise expired lots go first
+// unless skipExpired is set, then the earliest expiring. Unexpired stock held
+// by carts cannot be removed. Returns nil allocations if there is not enough
+// stock.
+func (s *StockService) Remove(tx *gorm.DB, itemID uuid.UUID, lotID *uuid.UUID, quantity int, skipExpired bool, ref models.StockMovement) ([]LotAllocation, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+	if err := s.expireLapsed(tx, &itemID, lapsedBatchSize); err != nil {
+		return nil, err
+	}
+
+	var allocations []LotAllocation
+	if lotID != nil {
+		lots, err := s.lotRepo.WithTx(tx).FindByIDsForUpdate([]uuid.UUID{*lotID})
+		if err != nil {
+			return nil, err
+		}
+		if len(lots) == 0 || lots[0].ItemID != itemID {
+			return nil, errors.New("lot not found for this item")
+		}
+		if lots[0].Quantity < quantity {
+			return nil, nil
+		}
+		allocations = []LotAllocation{{Lot: lots[0], Quantity: quantity}}
+	} else {
+		var err error
+		if allocations, err = s.allocate(tx, itemID, quantity, !skipExpired); err != nil || allocations == nil {
+			return nil, err
+		}
+	}
+
+	itemRepo := s.itemRepo.WithTx(tx)
+	unexpired := 0
+	for _, allocation := range allocations {
+		if !allocation.Lot.Expired {
+			unexpired += allocation.Quantity
+		}
+	}
+	if unexpired > 0 {
+		// The item stays locked, so no hold can be placed before the removal
+		item, err := itemRepo.FindForUpdate(itemID)
+		if err != nil {
+			return nil, err
+		}
+		if item.AvailableQuantity < unexpired {
+			return nil, nil
+		}
+	}
+
+	for _, allocation := range allocations {
+		ok, err := itemRepo.AdjustQuantity(itemID, -allocation.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errLotsOutOfSync
+		}
+		if err := s.debitLot(tx, &allocation.Lot, allocation.Quantity); err != nil {
+			return nil, err
+		}
+		if err := s.record(tx, itemID, &allocation.Lot, -allocation.Quantity, ref); err != nil {
+			return nil, err
+		}
+	}
+	return allocations, nil
+}
+
+// ExpireLots flags up to limit lots that passed their expiration date, moving
+// their remaining stock to the items' expired quantity, and returns how many
+// were flagged
+func (s *StockService) ExpireLots(limit int) (int, error) {
+	expired := 0
+	err := s.txManager.Transaction(func(tx *gorm.DB) error {
+		lots, err := s.lotRepo.WithTx(tx).FindLapsedForUpdate(nil, time.Now(), limit)
+		if err != nil {
+			return err
+		}
+		expired = len(lots)
+		return s.markExpired(tx, lots)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+// lapsedBatchSize bounds how many lapsed lots of a single item are flagged inline
+const lapsedBatchSize = 64
+
+// expireLapsed flags the lapsed lots of an item inside the caller's transaction
+func (s *StockService) expireLapsed(tx *gorm.DB, itemID *uuid.UUID, limit int) error {
+	lots, err := s.lotRepo.WithTx(tx).FindLapsedForUpdate(itemID, time.Now(), limit)
+	if err != nil {
+		return err
+	}
+	return s.markExpired(tx, lots)
+}
+
+// markExpired flags lots as expired and counts their stock as expired. Items
+// are updated in a stable order so concurrent sweeps cannot deadlock.
+func (s *StockService) markExpired(tx *gorm.DB, lots []models.ItemLot) error {
+	sort.Slice(lots, func(i, j int) bool {
+		return bytes.Compare(lots[i].ItemID[:], lots[j].ItemID[:]) < 0
+	})
+
+	lotRepo := s.lotRepo.WithTx(tx)
+	itemRepo := s.itemRepo.WithTx(tx)
+	for _, lot := range lots {
+		if err := lotRepo.MarkExpired(lot.ID); err != nil {
+			return err
+		}
+		if lot.Quantity > 0 {
+			if err := itemRepo.AdjustExpired(lot.ItemID, lot.Quantity); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// allocate picks lots to cover quantity in allocation order. Returns nil if the
+// lots cannot cover it.
+func (s *StockService) allocate(tx *gorm.DB, itemID uuid.UUID, quantity int, includeExpired bool) ([]LotAllocation, error) {
+	lots, err := s.lotRepo.WithTx(tx).FindAllocatable(itemID, includeExpired)
+	if err != nil {
+		return nil, err
+	}
+
+	var allocations []LotAllocation
+	remaining := quantity
+	for _, lot := range lots {
+		if remaining == 0 {
+			break
+		}
+		n := min(lot.Quantity, remaining)
+		allocations = append(allocations, LotAllocation{Lot: lot, Quantity: n})
+		remaining -= n
+	}
+	if remaining > 0 {
+		return nil, nil
+	}
+	return allocations, nil
+}
+
+// newLot creates an empty lot for an item
+func (s *StockService) newLot(tx *gorm.DB, itemID uuid.UUID, details LotDetails) (*models.ItemLot, error) {
+	item, err := s.itemRepo.WithTx(tx).FindForUpdate(itemID)
+	if err != nil {
+		return nil, err
+	}
+	lot := &models.ItemLot{
+		ItemID:     item.ID,
+		PantryID:   item.PantryID,
+		ReceivedAt: time.Now(),
+		ExpiresAt:  details.ExpiresAt,
+		Source:     details.Source,
+		DonationID: details.DonationID,
+	}
+	if err := s.lotRepo.WithTx(tx).Create(lot); err != nil {
+		return nil, err
+	}
+	return lot, nil
+}
+
+// creditLot adds stock to a lot, counting it as expired if the lot is
+func (s *StockService) creditLot(tx *gorm.DB, lot *models.ItemLot, quantity int) error {
+	if err := s.lotRepo.WithTx(tx).AdjustQuantity(lot.ID, quantity); err != nil {
+		return err
+	}
+	if lot.Expired {
+		return s.itemRepo.WithTx(tx).AdjustExpired(lot.ItemID, quantity)
+	}
+	return nil
+}
+
+// debitLot takes stock from a lot, uncounting it as expired if the lot is
+func (s *StockService) debitLot(tx *gorm.DB, lot *models.ItemLot, quantity int) error {
+	if err := s.lotRepo.WithTx(tx).AdjustQuantity(lot.ID, -quantity); err != nil {
+		return err
+	}
+	if lot.Expired {
+		return s.itemRepo.WithTx(tx).AdjustExpired(lot.ItemID, -quantity)
+	}
+	return nil
+}
+
+// record appends a movement of the given signed quantity to the ledger after
+// the item's stock was changed in the same transaction. The item row is locked
+// by that change, so the balance read back is the movement's own.
+func (s *StockService) record(tx *gorm.DB, itemID uuid.UUID, lot *models.ItemLot, quantity int, movement models.StockMovement) error {
+	item, err := s.itemRepo.WithTx(tx).FindForUpdate(itemID)
+	if err != nil {
+		return err
+	}
+	movement.ID = uuid.Nil
+	movement.ItemID = item.ID
+	movement.PantryID = item.PantryID
+	movement.Quantity = quantity
+	movement.BalanceAfter = item.Quantity
+	if lot != nil {
+		lotID := lot.ID
+		movement.LotID = &lotID
+	}
+	return s.movementRepo.WithTx(tx).Create(&movement)
+}
+
+// expiresBefore orders lots first expiring first, lots without a date last
+func expiresBefore(a, b models.ItemLot) bool {
+	switch {
+	case a.ExpiresAt == nil:
+		return false
+	case b.ExpiresAt == nil:
+		return true
+	default:
+		return a.ExpiresAt.Before(*b.ExpiresAt)
+	}
+}
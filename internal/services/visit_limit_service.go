@@ -0,0 +1,240 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/google/uuid"
+)
+
+// VisitLimitService handles business logic for household visit limits
+type VisitLimitService struct {
+	visitLimitRepo *repositories.VisitLimitRepository
+	pantryRepo     *repositories.PantryRepository
+	orderRepo      *repositories.OrderRepository
+}
+
+// NewVisitLimitService creates a new visit limit service
+func NewVisitLimitService(visitLimitRepo *repositories.VisitLimitRepository, pantryRepo *repositories.PantryRepository, orderRepo *repositories.OrderRepository) *VisitLimitService {
+	return &VisitLimitService{
+		visitLimitRepo: visitLimitRepo,
+		pantryRepo:     pantryRepo,
+		orderRepo:      orderRepo,
+	}
+}
+
+// VisitLimitError is returned by Checkout when a household has reached a
+// pantry's visit limit
+type VisitLimitError struct {
+	MaxOrders  int
+	WindowDays int
+	EligibleAt time.Time // In pantry local time
+}
+
+func (e *VisitLimitError) Error() string {
+	return fmt.Sprintf("visit limit of %d order(s) per %d day(s) reached; eligible again on %s",
+		e.MaxOrders, e.WindowDays, e.EligibleAt.Format("Mon Jan 2, 2006 at 3:04 PM MST"))
+}
+
+// CreateVisitLimitRequest represents a request to create a visit limit policy
+type CreateVisitLimitRequest struct {
+	MaxOrders   int    `json:"max_orders" binding:"required,min=1"`
+	WindowDays  int    `json:"window_days" binding:"required,min=1"`
+	Description string `json:"description"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// UpdateVisitLimitRequest represents a request to update a visit limit policy
+type UpdateVisitLimitRequest struct {
+	MaxOrders   *int    `json:"max_orders" binding:"omitempty,min=1"`
+	WindowDays  *int    `json:"window_days" binding:"omitempty,min=1"`
+	Description *string `json:"description"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// VisitLimitStatus reports how much of one policy a household has used
+type VisitLimitStatus struct {
+	PolicyID       uuid.UUID  `json:"policy_id"`
+	MaxOrders      int        `json:"max_orders"`
+	WindowDays     int        `json:"window_days"`
+	Description    string     `json:"description"`
+	OrdersInWindow int        `json:"orders_in_window"`
+	Remaining      int        `json:"remaining"`
+	EligibleAt     *time.Time `json:"eligible_at,omitempty"`
+}
+
+// Eligibility reports whether a household may place an order at a pantry
+type Eligibility struct {
+	PantryID   uuid.UUID          `json:"pantry_id"`
+	Eligible   bool               `json:"eligible"`
+	EligibleAt *time.Time         `json:"eligible_at,omitempty"` // Set when not eligible
+	Limits     []VisitLimitStatus `json:"limits"`
+}
+
+// CreatePolicy creates a new visit limit policy for a pantry
+func (s *VisitLimitService) CreatePolicy(pantryID uuid.UUID, req *CreateVisitLimitRequest) (*models.VisitLimitPolicy, error) {
+	if _, err := s.pantryRepo.FindByID(pantryID); err != nil {
+		return nil, err
+	}
+
+	policy := &models.VisitLimitPolicy{
+		PantryID:    pantryID,
+		MaxOrders:   req.MaxOrders,
+		WindowDays:  req.WindowDays,
+		Description: req.Description,
+		IsActive:    true,
+	}
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+
+	if err := s.visitLimitRepo.Create(policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// GetPolicies retrieves all visit limit policies for a pantry
+func (s *VisitLimitService) GetPolicies(pantryID uuid.UUID) ([]models.VisitLimitPolicy, error) {
+	return s.visitLimitRepo.FindByPantryID(pantryID)
+}
+
+// UpdatePolicy updates a visit limit policy
+func (s *VisitLimitService) UpdatePolicy(id uuid.UUID, req *UpdateVisitLimitRequest) (*models.VisitLimitPolicy, error) {
+	policy, err := s.visitLimitRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MaxOrders != nil {
+		policy.MaxOrders = *req.MaxOrders
+	}
+	if req.WindowDays != nil {
+		policy.WindowDays = *req.WindowDays
+	}
+	if req.Description != nil {
+		policy.Description = *req.Description
+	}
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+
+	if err := s.visitLimitRepo.Update(policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// DeletePolicy deletes a visit limit policy
+func (s *VisitLimitService) DeletePolicy(id uuid.UUID) error {
+	if _, err := s.visitLimitRepo.FindByID(id); err != nil {
+		return err
+	}
+
+	return s.visitLimitRepo.Delete(id)
+}
+
+// CheckEligibility reports whether a user may currently order from a pantry
+func (s *VisitLimitService) CheckEligibility(userID, pantryID uuid.UUID) (*Eligibility, error) {
+	pantry, err := s.pantryRepo.FindByID(pantryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluateVisitLimits(s.visitLimitRepo, s.orderRepo, userID, pantry, time.Now())
+}
+
+// evaluateVisitLimits loads the pantry's active policies and the user's recent
+// orders there and evaluates them with applyVisitLimits
+func evaluateVisitLimits(visitLimitRepo *repositories.VisitLimitRepository, orderRepo *repositories.OrderRepository, userID uuid.UUID, pantry *models.Pantry, now time.Time) (*Eligibility, error) {
+	policies, err := visitLimitRepo.FindActiveByPantryID(pantry.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return applyVisitLimits(pantry, nil, nil, now), nil
+	}
+
+	// One query covers every policy since they are sorted by window length
+	longest := policies[len(policies)-1].Window()
+	submitted, err := orderRepo.FindSubmittedTimesSince(userID, pantry.ID, now.Add(-longest),
+		[]models.OrderStatus{models.OrderStatusCancelled})
+	if err != nil {
+		return nil, err
+	}
+
+	return applyVisitLimits(pantry, policies, submitted, now), nil
+}
+
+// applyVisitLimits counts the submission times, newest first, in the window of
+// each policy. A policy is exhausted once the window holds MaxOrders orders; it
+// frees up when the oldest of those leaves the window.
+func applyVisitLimits(pantry *models.Pantry, policies []models.VisitLimitPolicy, submitted []time.Time, now time.Time) *Eligibility {
+	eligibility := &Eligibility{
+		PantryID: pantry.ID,
+		Eligible: true,
+		Limits:   []VisitLimitStatus{},
+	}
+
+	loc := pantry.Location()
+	for _, policy := range policies {
+		since := now.Add(-policy.Window())
+		count := 0
+		for _, t := range submitted {
+			if t.After(since) {
+				count++
+			}
+		}
+
+		status := VisitLimitStatus{
+			PolicyID:       policy.ID,
+			MaxOrders:      policy.MaxOrders,
+			WindowDays:     policy.WindowDays,
+			Description:    policy.Description,
+			OrdersInWindow: count,
+			Remaining:      policy.MaxOrders - count,
+		}
+		if status.Remaining <= 0 {
+			status.Remaining = 0
+			eligibleAt := submitted[policy.MaxOrders-1].Add(policy.Window()).In(loc)
+			status.EligibleAt = &eligibleAt
+
+			eligibility.Eligible = false
+			if eligibility.EligibleAt == nil || eligibleAt.After(*eligibility.EligibleAt) {
+				eligibility.EligibleAt = &eligibleAt
+			}
+		}
+		eligibility.Limits = append(eligibility.Limits, status)
+	}
+
+	return eligibility
+}
+
+// checkVisitLimits returns a VisitLimitError when the user may not order from
+// the pantry yet
+func checkVisitLimits(visitLimitRepo *repositories.VisitLimitRepository, orderRepo *repositories.OrderRepository, userID uuid.UUID, pantry *models.Pantry) error {
+	eligibility, err := evaluateVisitLimits(visitLimitRepo, orderRepo, userID, pantry, time.Now())
+	if err != nil {
+		return err
+	}
+	if eligibility.Eligible {
+		return nil
+	}
+
+	// Report the policy that keeps the household waiting the longest
+	for _, limit := range eligibility.Limits {
+		if limit.EligibleAt != nil && limit.EligibleAt.Equal(*eligibility.EligibleAt) {
+			return &VisitLimitError{
+				MaxOrders:  limit.MaxOrders,
+				WindowDays: limit.WindowDays,
+				EligibleAt: *limit.EligibleAt,
+			}
+		}
+	}
+	return errors.New("visit limit reached")
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+)
+
+func TestApplyVisitLimits(t *testing.T) {
+	pantry := &models.Pantry{Timezone: "America/Chicago"}
+	now := time.Date(2026, 3, 20, 18, 0, 0, 0, time.UTC)
+	daysAgo := func(days float64) time.Time {
+		return now.Add(-time.Duration(days * float64(24*time.Hour)))
+	}
+	weekly := models.VisitLimitPolicy{MaxOrders: 1, WindowDays: 7}
+	monthly := models.VisitLimitPolicy{MaxOrders: 2, WindowDays: 30}
+
+	tests := []struct {
+		name       string
+		policies   []models.VisitLimitPolicy
+		submitted  []time.Time // Newest first
+		wantCounts []int
+		wantAt     time.Time // Zero when eligible
+	}{
+		{
+			name:       "no policies",
+			submitted:  []time.Time{daysAgo(1)},
+			wantCounts: []int{},
+		},
+		{
+			name:       "no orders",
+			policies:   []models.VisitLimitPolicy{weekly},
+			wantCounts: []int{0},
+		},
+		{
+			name:       "order outside the window",
+			policies:   []models.VisitLimitPolicy{weekly},
+			submitted:  []time.Time{daysAgo(8)},
+			wantCounts: []int{0},
+		},
+		{
+			name:       "order exactly one window ago",
+			policies:   []models.VisitLimitPolicy{weekly},
+			submitted:  []time.Time{daysAgo(7)},
+			wantCounts: []int{0},
+		},
+		{
+			name:       "limit reached",
+			policies:   []models.VisitLimitPolicy{weekly},
+			submitted:  []time.Time{daysAgo(2)},
+			wantCounts: []int{1},
+			wantAt:     daysAgo(2).Add(7 * 24 * time.Hour),
+		},
+		{
+			name:       "frees up when the oldest counted order leaves the window",
+			policies:   []models.VisitLimitPolicy{monthly},
+			submitted:  []time.Time{daysAgo(3), daysAgo(10), daysAgo(40)},
+			wantCounts: []int{2},
+			wantAt:     daysAgo(10).Add(30 * 24 * time.Hour),
+		},
+		{
+			name:       "under the longer limit",
+			policies:   []models.VisitLimitPolicy{weekly, monthly},
+			submitted:  []time.Time{daysAgo(10)},
+			wantCounts: []int{0, 1},
+		},
+		{
+			name:       "latest eligibility wins across policies",
+			policies:   []models.VisitLimitPolicy{weekly, monthly},
+			submitted:  []time.Time{daysAgo(1), daysAgo(20)},
+			wantCounts: []int{1, 2},
+			wantAt:     daysAgo(20).Add(30 * 24 * time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyVisitLimits(pantry, tt.policies, tt.submitted, now)
+
+			if len(got.Limits) != len(tt.wantCounts) {
+				t.Fatalf("got %d limits, want %d", len(got.Limits), len(tt.wantCounts))
+			}
+			for i, limit := range got.Limits {
+				if limit.OrdersInWindow != tt.wantCounts[i] {
+					t.Errorf("limit %d: OrdersInWindow = %d, want %d", i, limit.OrdersInWindow, tt.wantCounts[i])
+				}
+				if want := max(limit.MaxOrders-tt.wantCounts[i], 0); limit.Remaining != want {
+					t.Errorf("limit %d: Remaining = %d, want %d", i, limit.Remaining, want)
+				}
+			}
+
+			if tt.wantAt.IsZero() {
+				if !got.Eligible || got.EligibleAt != nil {
+					t.Errorf("Eligible = %v, EligibleAt = %v, want eligible", got.Eligible, got.EligibleAt)
+				}
+				return
+			}
+			if got.Eligible || got.EligibleAt == nil {
+				t.Fatalf("Eligible = %v, EligibleAt = %v, want ineligible until %s", got.Eligible, got.EligibleAt, tt.wantAt)
+			}
+			if !got.EligibleAt.Equal(tt.wantAt) {
+				t.Errorf("EligibleAt = %s, want %s", got.EligibleAt, tt.wantAt)
+			}
+			if loc := got.EligibleAt.Location().String(); loc != pantry.Timezone {
+				t.Errorf("EligibleAt location = %s, want %s", loc, pantry.Timezone)
+			}
+		})
+	}
+}
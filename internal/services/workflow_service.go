@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/byte4bite/byte4bite/internal/models"
+	"github.com/byte4bite/byte4bite/internal/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WorkflowService handles business logic for per-pantry order workflows
+type WorkflowService struct {
+	workflowRepo *repositories.WorkflowRepository
+	pantryRepo   *repositories.PantryRepository
+	orderRepo    *repositories.OrderRepository
+	txManager    *repositories.TxManager
+}
+
+// NewWorkflowService creates a new workflow service
+func NewWorkflowService(workflowRepo *repositories.WorkflowRepository, pantryRepo *repositories.PantryRepository, orderRepo *repositories.OrderRepository, txManager *repositories.TxManager) *WorkflowService {
+	return &WorkflowService{
+		workflowRepo: workflowRepo,
+		pantryRepo:   pantryRepo,
+		orderRepo:    orderRepo,
+		txManager:    txManager,
+	}
+}
+
+// WorkflowStateRequest describes one state of a workflow
+type WorkflowStateRequest struct {
+	Status        models.OrderStatus `json:"status" binding:"required"`
+	Label         string             `json:"label"`
+	IsFinal       bool               `json:"is_final"`
+	StaffEditable bool               `json:"staff_editable"`
+}
+
+// WorkflowTransitionRequest describes one allowed transition of a workflow
+type WorkflowTransitionRequest struct {
+	FromStatus        models.OrderStatus `json:"from_status" binding:"required"`
+	ToStatus          models.OrderStatus `json:"to_status" binding:"required"`
+	RestoresInventory bool               `json:"restores_inventory"`
+	StampField        string             `json:"stamp_field"`
+	ClientAllowed     bool               `json:"client_allowed"`
+}
+
+// SetWorkflowRequest represents a request to define a pantry's workflow
+type SetWorkflowRequest struct {
+	Name          string                      `json:"name"`
+	InitialStatus models.OrderStatus          `json:"initial_status" binding:"required"`
+	States        []WorkflowStateRequest      `json:"states" binding:"required,min=1,dive"`
+	Transitions   []WorkflowTransitionRequest `json:"transitions" binding:"required,dive"`
+}
+
+// GetWorkflow returns the workflow in effect for a pantry and fulfillment type
+func (s *WorkflowService) GetWorkflow(pantryID uuid.UUID, fulfillment models.FulfillmentType) (*models.OrderWorkflow, error) {
+	if err := validateFulfillmentType(fulfillment); err != nil {
+		return nil, err
+	}
+	if _, err := s.pantryRepo.FindByID(pantryID); err != nil {
+		return nil, err
+	}
+	return s.workflowRepo.FindForPantry(pantryID, fulfillment)
+}
+
+// SetWorkflow replaces a pantry's workflow for a fulfillment type. It is rejected
+// if open orders are in a state the new workflow no longer defines.
+func (s *WorkflowService) SetWorkflow(pantryID uuid.UUID, fulfillment models.FulfillmentType, req *SetWorkflowRequest) (*models.OrderWorkflow, error) {
+	if err := validateFulfillmentType(fulfillment); err != nil {
+		return nil, err
+	}
+
+	pantry, err := s.pantryRepo.FindByID(pantryID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = pantry.Name + " " + string(fulfillment) + " workflow"
+	}
+
+	workflow := &models.OrderWorkflow{
+		PantryID:        &pantryID,
+		FulfillmentType: fulfillment,
+		Name:            name,
+		InitialStatus:   req.InitialStatus,
+	}
+	for _, state := range req.States {
+		workflow.States = append(workflow.States, models.WorkflowState{
+			Status:        state.Status,
+			Label:         state.Label,
+			IsFinal:       state.IsFinal,
+			StaffEditable: state.StaffEditable,
+		})
+	}
+	for _, transition := range req.Transitions {
+		workflow.Transitions = append(workflow.Transitions, models.WorkflowTransition{
+			FromStatus:        transition.FromStatus,
+			ToStatus:          transition.ToStatus,
+			RestoresInventory: transition.RestoresInventory,
+			StampField:        transition.StampField,
+			ClientAllowed:     transition.ClientAllowed,
+		})
+	}
+
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+
+	err = s.txManager.Transaction(func(tx *gorm.DB) error {
+		workflowRepo := s.workflowRepo.WithTx(tx)
+
+		if err := s.checkOpenOrdersCovered(s.orderRepo.WithTx(tx), workflowRepo, pantryID, workflow); err != nil {
+			return err
+		}
+
+		existing, err := workflowRepo.FindByPantryID(pantryID, fulfillment)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			if err := workflowRepo.Delete(existing.ID); err != nil {
+				return err
+			}
+		}
+
+		return workflowRepo.Create(workflow)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.workflowRepo.FindByPantryID(pantryID, fulfillment)
+}
+
+// ResetWorkflow removes a pantry's own workflow for a fulfillment type so it
+// falls back to the default
+func (s *WorkflowService) ResetWorkflow(pantryID uuid.UUID, fulfillment models.FulfillmentType) error {
+	if err := validateFulfillmentType(fulfillment); err != nil {
+		return err
+	}
+
+	return s.txManager.Transaction(func(tx *gorm.DB) error {
+		workflowRepo := s.workflowRepo.WithTx(tx)
+
+		existing, err := workflowRepo.FindByPantryID(pantryID, fulfillment)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return errors.New("pantry already uses the default workflow")
+		}
+
+		defaultWorkflow, err := workflowRepo.FindDefault(fulfillment)
+		if err != nil {
+			return err
+		}
+		if err := s.checkOpenOrdersCovered(s.orderRepo.WithTx(tx), workflowRepo, pantryID, defaultWorkflow); err != nil {
+			return err
+		}
+
+		return workflowRepo.Delete(existing.ID)
+	})
+}
+
+// checkOpenOrdersCovered ensures every open order of the pantry handled by the
+// replacement workflow is in a state that it still defines
+func (s *WorkflowService) checkOpenOrdersCovered(orderRepo *repositories.OrderRepository, workflowRepo *repositories.WorkflowRepository, pantryID uuid.UUID, replacement *models.OrderWorkflow) error {
+	current, err := workflowRepo.FindForPantry(pantryID, replacement.FulfillmentType)
+	if err != nil {
+		return err
+	}
+
+	var closed []models.OrderStatus
+	for _, state := range current.States {
+		if state.IsFinal {
+			closed = append(closed, state.Status)
+		}
+	}
+
+	statuses, err := orderRepo.FindOpenStatuses(pantryID, replacement.FulfillmentType, closed)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, status := range statuses {
+		if replacement.FindState(status) == nil {
+			missing = append(missing, string(status))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("open orders are still in states removed by this workflow: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// validateFulfillmentType checks that a fulfillment type is supported
+func validateFulfillmentType(fulfillment models.FulfillmentType) error {
+	if fulfillment != models.FulfillmentPickup && fulfillment != models.FulfillmentDelivery {
+		return errors.New("fulfillment_type must be pickup or delivery")
+	}
+	return nil
+}
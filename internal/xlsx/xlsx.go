@@ -0,0 +1,447 @@
+// Package xlsx reads and writes single-sheet Excel workbooks for spreadsheet
+// imports and exports. Only cell values are supported: reading returns the
+// first worksheet as text, and writing produces strings, numbers and booleans
+// without styles.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Limits guarding against oversized or malicious workbooks
+const (
+	MaxRows    = 1 << 20 // Excel's own row limit
+	MaxColumns = 1 << 14 // Excel's own column limit
+	maxPart    = 64 << 20
+)
+
+var (
+	// ErrInvalid is returned for files that are not readable workbooks
+	ErrInvalid = errors.New("file is not a valid XLSX workbook")
+	// ErrTooLarge is returned for sheets with data beyond the row or column limit
+	ErrTooLarge = errors.New("workbook has more rows or columns than allowed")
+)
+
+// Read returns the rows of a workbook's first worksheet, with every cell as
+// text. Rows keep their position in the sheet, so blank rows between data rows
+// come back empty; trailing empty cells and rows are dropped. The sheet is
+// streamed, and data beyond maxRows rows or maxColumns columns fails with
+// ErrTooLarge before it is held in memory.
+func Read(r io.ReaderAt, size int64, maxRows, maxColumns int) ([][]string, error) {
+	maxRows = min(maxRows, MaxRows)
+	maxColumns = min(maxColumns, MaxColumns)
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		// A sheet within the limits cannot refer to more strings than it has cells
+		if shared, err = readSharedStrings(f, maxRows*maxColumns); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, ErrInvalid
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	defer rc.Close()
+
+	decoder := xml.NewDecoder(io.LimitReader(rc, maxPart))
+	var rows [][]string
+	next := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalid
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		// Rows and cells may omit their position, meaning the next one
+		index := next
+		if ref := attr(start, "r"); ref != "" {
+			n, err := strconv.Atoi(ref)
+			if err != nil || n < 1 || n > MaxRows {
+				return nil, ErrInvalid
+			}
+			index = n - 1
+		}
+		if index < next {
+			return nil, ErrInvalid
+		}
+		next = index + 1
+
+		values, err := readRow(decoder, shared, maxColumns)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if index >= maxRows {
+			return nil, ErrTooLarge
+		}
+		for len(rows) < index {
+			rows = append(rows, nil)
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// cell is a worksheet cell as stored in the sheet XML
+type cell struct {
+	R  string    `xml:"r,attr"`
+	T  string    `xml:"t,attr"`
+	V  string    `xml:"v"`
+	IS *richText `xml:"is"`
+}
+
+// value returns the cell's text, resolving shared strings and booleans
+func (c *cell) value(shared []string) (string, error) {
+	switch c.T {
+	case "s":
+		i, err := strconv.Atoi(c.V)
+		if err != nil || i < 0 || i >= len(shared) {
+			return "", ErrInvalid
+		}
+		return shared[i], nil
+	case "inlineStr":
+		if c.IS == nil {
+			return "", nil
+		}
+		return c.IS.text(), nil
+	case "b":
+		if c.V == "1" {
+			return "true", nil
+		}
+		return "false", nil
+	}
+	return c.V, nil
+}
+
+// readRow reads the cells of a row up to its end element. Empty cells are
+// only kept where they sit between values.
+func readRow(decoder *xml.Decoder, shared []string, maxColumns int) ([]string, error) {
+	var values []string
+	next := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, ErrInvalid
+		}
+		switch t := token.(type) {
+		case xml.EndElement:
+			return values, nil
+		case xml.StartElement:
+			if t.Name.Local != "c" {
+				if err := decoder.Skip(); err != nil {
+					return nil, ErrInvalid
+				}
+				continue
+			}
+			var c cell
+			if err := decoder.DecodeElement(&c, &t); err != nil {
+				return nil, ErrInvalid
+			}
+
+			col := next
+			if c.R != "" {
+				if col, err = column(c.R); err != nil {
+					return nil, err
+				}
+			}
+			if col < next {
+				return nil, ErrInvalid
+			}
+			next = col + 1
+
+			value, err := c.value(shared)
+			if err != nil {
+				return nil, err
+			}
+			if value == "" {
+				continue
+			}
+			if col >= maxColumns {
+				return nil, ErrTooLarge
+			}
+			for len(values) < col {
+				values = append(values, "")
+			}
+			values = append(values, value)
+		}
+	}
+}
+
+// readSharedStrings streams the shared string table, allowing at most max entries
+func readSharedStrings(f *zip.File, max int) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	defer rc.Close()
+
+	decoder := xml.NewDecoder(io.LimitReader(rc, maxPart))
+	var shared []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return shared, nil
+		}
+		if err != nil {
+			return nil, ErrInvalid
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "si" {
+			continue
+		}
+		if len(shared) >= max {
+			return nil, ErrTooLarge
+		}
+		var item richText
+		if err := decoder.DecodeElement(&item, &start); err != nil {
+			return nil, ErrInvalid
+		}
+		shared = append(shared, item.text())
+	}
+}
+
+// attr returns the value of an element's attribute, or "" if it is not set
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// richText is a shared or inline string, either plain or made of formatted runs
+type richText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t richText) text() string {
+	var b strings.Builder
+	b.WriteString(t.T)
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+// firstSheet finds the path of the workbook's first worksheet
+func firstSheet(files map[string]*zip.File) (string, error) {
+	workbookFile, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", ErrInvalid
+	}
+	var workbook struct {
+		Sheets []struct {
+			RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(workbookFile, &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", ErrInvalid
+	}
+
+	relsFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(relsFile, &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		// Targets are relative to xl/ unless absolute within the package
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", ErrInvalid
+}
+
+// decodePart unmarshals an XML part of the package
+func decodePart(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return ErrInvalid
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(rc, maxPart)).Decode(v); err != nil {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// column converts a cell reference such as "AB12" to a zero-based column index
+func column(ref string) (int, error) {
+	col := 0
+	for _, r := range ref {
+		if r >= '0' && r <= '9' {
+			break
+		}
+		if r < 'A' || r > 'Z' {
+			return 0, ErrInvalid
+		}
+		col = col*26 + int(r-'A') + 1
+		if col > MaxColumns {
+			return 0, ErrInvalid
+		}
+	}
+	if col == 0 {
+		return 0, ErrInvalid
+	}
+	return col - 1, nil
+}
+
+// Writer streams rows into a single-sheet workbook
+type Writer struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	rows  int
+}
+
+// NewWriter starts a workbook with one sheet of the given name on w
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + escape(sheetName) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+	}
+	for _, part := range parts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{zw: zw, sheet: sheet}, nil
+}
+
+// WriteRow appends a row. Ints and floats are written as numbers, bools as
+// booleans and anything else as text.
+func (w *Writer) WriteRow(values ...interface{}) error {
+	if w.rows >= MaxRows {
+		return errors.New("too many rows for a worksheet")
+	}
+	w.rows++
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, w.rows)
+	for _, value := range values {
+		switch v := value.(type) {
+		case int:
+			fmt.Fprintf(&b, `<c><v>%d</v></c>`, v)
+		case float64:
+			fmt.Fprintf(&b, `<c><v>%s</v></c>`, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			n := 0
+			if v {
+				n = 1
+			}
+			fmt.Fprintf(&b, `<c t="b"><v>%d</v></c>`, n)
+		default:
+			text := fmt.Sprint(v)
+			if text == "" {
+				b.WriteString(`<c/>`)
+				continue
+			}
+			b.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">` + escape(text) + `</t></is></c>`)
+		}
+	}
+	b.WriteString(`</row>`)
+
+	_, err := io.WriteString(w.sheet, b.String())
+	return err
+}
+
+// Close finishes the workbook; it does not close the underlying writer
+func (w *Writer) Close() error {
+	if _, err := io.WriteString(w.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// escape escapes text for XML content and attributes
+func escape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
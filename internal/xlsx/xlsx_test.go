@@ -0,0 +1,260 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+const (
+	workbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Items" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	workbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+)
+
+// workbook builds a package from the given parts
+func workbook(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// sheet wraps sheet data in a worksheet part
+func sheet(data string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + data + `</sheetData></worksheet>`
+}
+
+func TestWriteRead(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Items & Stock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]interface{}{
+		{"name", "quantity", "weight", "available"},
+		{"Rice <2kg>", 12, 2.5, true},
+		{"", 0, 0.25, false},
+		{"Beans \"black\"", -3, 1.0, true},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()), MaxRows, MaxColumns)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	want := [][]string{
+		{"name", "quantity", "weight", "available"},
+		{"Rice <2kg>", "12", "2.5", "true"},
+		{"", "0", "0.25", "false"},
+		{"Beans \"black\"", "-3", "1", "true"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read = %q, want %q", got, want)
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		shared string
+		data   string
+		want   [][]string
+	}{
+		{
+			name: "sparse rows and cells",
+			data: `<row r="1"><c r="A1" t="inlineStr"><is><t>name</t></is></c><c r="C1" t="inlineStr"><is><t>quantity</t></is></c></row>` +
+				`<row r="4"><c r="B4"><v>7</v></c><c r="D4" t="b"><v>1</v></c></row>`,
+			want: [][]string{
+				{"name", "", "quantity"},
+				nil,
+				nil,
+				{"", "7", "", "true"},
+			},
+		},
+		{
+			name: "trailing empty cells and rows",
+			data: `<row r="1"><c r="A1"><v>1</v></c><c r="B1"/><c r="XFD1" t="inlineStr"><is><t></t></is></c></row>` +
+				`<row r="2"><c r="A2"><v>2</v></c></row><row r="1048576"><c r="A1048576"/></row>`,
+			want: [][]string{{"1"}, {"2"}},
+		},
+		{
+			name: "rows and cells without positions",
+			data: `<row><c><v>1</v></c><c><v>2</v></c></row><row><c><v>3</v></c></row>`,
+			want: [][]string{{"1", "2"}, {"3"}},
+		},
+		{
+			name: "shared strings",
+			shared: `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="3" uniqueCount="3">` +
+				`<si><t>name</t></si><si><t>Pasta</t></si><si><r><t>Whole </t></r><r><t>wheat</t></r></si></sst>`,
+			data: `<row r="1"><c r="A1" t="s"><v>0</v></c></row>` +
+				`<row r="2"><c r="A2" t="s"><v>1</v></c><c r="B2" t="s"><v>2</v></c><c r="C2" t="s"><v>1</v></c></row>`,
+			want: [][]string{{"name"}, {"Pasta", "Whole wheat", "Pasta"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := map[string]string{
+				"xl/workbook.xml":            workbookXML,
+				"xl/_rels/workbook.xml.rels": workbookRels,
+				"xl/worksheets/sheet1.xml":   sheet(tt.data),
+			}
+			if tt.shared != "" {
+				parts["xl/sharedStrings.xml"] = tt.shared
+			}
+			file := workbook(t, parts)
+
+			got, err := Read(bytes.NewReader(file), int64(len(file)), MaxRows, MaxColumns)
+			if err != nil {
+				t.Fatalf("Read returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts map[string]string
+	}{
+		{
+			name:  "no workbook",
+			parts: map[string]string{"xl/worksheets/sheet1.xml": sheet("")},
+		},
+		{
+			name: "missing sheet",
+			parts: map[string]string{
+				"xl/workbook.xml":            workbookXML,
+				"xl/_rels/workbook.xml.rels": workbookRels,
+			},
+		},
+		{
+			name: "shared string out of range",
+			parts: map[string]string{
+				"xl/workbook.xml":            workbookXML,
+				"xl/_rels/workbook.xml.rels": workbookRels,
+				"xl/worksheets/sheet1.xml":   sheet(`<row r="1"><c r="A1" t="s"><v>3</v></c></row>`),
+			},
+		},
+		{
+			name: "rows out of order",
+			parts: map[string]string{
+				"xl/workbook.xml":            workbookXML,
+				"xl/_rels/workbook.xml.rels": workbookRels,
+				"xl/worksheets/sheet1.xml":   sheet(`<row r="2"><c><v>1</v></c></row><row r="1"><c><v>2</v></c></row>`),
+			},
+		},
+		{
+			name: "bad cell reference",
+			parts: map[string]string{
+				"xl/workbook.xml":            workbookXML,
+				"xl/_rels/workbook.xml.rels": workbookRels,
+				"xl/worksheets/sheet1.xml":   sheet(`<row r="1"><c r="1A"><v>1</v></c></row>`),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := workbook(t, tt.parts)
+			if _, err := Read(bytes.NewReader(file), int64(len(file)), MaxRows, MaxColumns); err != ErrInvalid {
+				t.Errorf("Read returned %v, want ErrInvalid", err)
+			}
+		})
+	}
+
+	t.Run("not a zip", func(t *testing.T) {
+		data := []byte("name,quantity\nRice,1\n")
+		if _, err := Read(bytes.NewReader(data), int64(len(data)), MaxRows, MaxColumns); err != ErrInvalid {
+			t.Errorf("Read returned %v, want ErrInvalid", err)
+		}
+	})
+}
+
+func TestReadLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		shared     string
+		data       string
+		maxRows    int
+		maxColumns int
+		wantErr    error
+	}{
+		{
+			name:       "within limits",
+			data:       `<row r="3"><c r="C3"><v>1</v></c></row>`,
+			maxRows:    3,
+			maxColumns: 3,
+		},
+		{
+			name:       "row beyond limit",
+			data:       `<row r="1"><c><v>1</v></c></row><row r="1048576"><c><v>2</v></c></row>`,
+			maxRows:    10,
+			maxColumns: 10,
+			wantErr:    ErrTooLarge,
+		},
+		{
+			name:       "column beyond limit",
+			data:       `<row r="1"><c r="XFD1"><v>1</v></c></row>`,
+			maxRows:    10,
+			maxColumns: 10,
+			wantErr:    ErrTooLarge,
+		},
+		{
+			name:       "empty cells beyond limits",
+			data:       `<row r="1"><c r="A1"><v>1</v></c><c r="XFD1"/></row><row r="1048576"><c r="A1048576"/></row>`,
+			maxRows:    10,
+			maxColumns: 10,
+		},
+		{
+			name: "more shared strings than cells",
+			shared: `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+				`<si><t>a</t></si><si><t>b</t></si><si><t>c</t></si><si><t>d</t></si><si><t>e</t></si></sst>`,
+			data:       `<row r="1"><c r="A1" t="s"><v>0</v></c></row>`,
+			maxRows:    2,
+			maxColumns: 2,
+			wantErr:    ErrTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := map[string]string{
+				"xl/workbook.xml":            workbookXML,
+				"xl/_rels/workbook.xml.rels": workbookRels,
+				"xl/worksheets/sheet1.xml":   sheet(tt.data),
+			}
+			if tt.shared != "" {
+				parts["xl/sharedStrings.xml"] = tt.shared
+			}
+			file := workbook(t, parts)
+
+			if _, err := Read(bytes.NewReader(file), int64(len(file)), tt.maxRows, tt.maxColumns); err != tt.wantErr {
+				t.Errorf("Read returned %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}